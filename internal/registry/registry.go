@@ -0,0 +1,93 @@
+// Package registry keeps track of running goroutines and tasks, for the
+// introspection methods of conc.WaitGroup and the pools.
+package registry
+
+import (
+	"runtime"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/sourcegraph/conc/panics"
+)
+
+// Registry keeps track of running goroutines or tasks. The zero value is
+// ready to use.
+type Registry struct {
+	mu      sync.Mutex
+	running map[uint64]registered
+	nextID  uint64
+}
+
+type registered struct {
+	name    string
+	started time.Time
+	pc      uintptr
+}
+
+// Entry describes a registered goroutine or task.
+type Entry struct {
+	Name     string
+	Started  time.Time
+	Location panics.Frame
+}
+
+// Add registers a goroutine or task spawned from pc, as returned by
+// runtime.Callers, and returns its ID.
+func (r *Registry) Add(name string, pc uintptr) uint64 {
+	started := time.Now()
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.running == nil {
+		r.running = make(map[uint64]registered)
+	}
+	r.nextID++
+	r.running[r.nextID] = registered{name: name, started: started, pc: pc}
+	return r.nextID
+}
+
+// Remove unregisters the goroutine or task with the given ID.
+func (r *Registry) Remove(id uint64) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.running, id)
+}
+
+// Snapshot returns the registered goroutines or tasks in the order they were
+// added.
+func (r *Registry) Snapshot() []Entry {
+	r.mu.Lock()
+	ids := make([]uint64, 0, len(r.running))
+	for id := range r.running {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+	entries := make([]registered, len(ids))
+	for i, id := range ids {
+		entries[i] = r.running[id]
+	}
+	r.mu.Unlock()
+
+	// Decoding locations is comparatively slow, so it's done without holding
+	// the lock.
+	res := make([]Entry, len(entries))
+	for i, e := range entries {
+		res[i] = Entry{
+			Name:     e.name,
+			Started:  e.started,
+			Location: location(e.pc),
+		}
+	}
+	return res
+}
+
+// location decodes a program counter as returned by runtime.Callers.
+func location(pc uintptr) panics.Frame {
+	frame, _ := runtime.CallersFrames([]uintptr{pc}).Next()
+	return panics.Frame{
+		Function: frame.Function,
+		File:     frame.File,
+		Line:     frame.Line,
+	}
+}
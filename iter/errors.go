@@ -0,0 +1,101 @@
+package iter
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/sourcegraph/conc/panics"
+)
+
+// ElementError is an error that occurred while processing the element of
+// the input at Index.
+type ElementError struct {
+	// Index is the index of the element in the input.
+	Index int
+	// Err is the underlying error.
+	Err error
+}
+
+func (e *ElementError) Error() string {
+	return fmt.Sprintf("element %d: %s", e.Index, e.Err)
+}
+
+func (e *ElementError) Unwrap() error {
+	return e.Err
+}
+
+// IndexedErrors is the error returned by MapErr and FlatMapErr when the
+// callback returned an error for at least one element. It records the index
+// of each element that failed, so callers can tell which results are
+// invalid.
+//
+// IndexedErrors unwraps to the errors of all failed elements, in input
+// order, so it can be used with errors.Is and errors.As like the result of
+// errors.Join.
+type IndexedErrors struct {
+	indices []int
+	errs    []error
+}
+
+// newIndexedErrors returns an *IndexedErrors for the non-nil errors in errs,
+// which holds an error for each element, or nil if all errors are nil.
+func newIndexedErrors(errs []error) error {
+	var e IndexedErrors
+	for i, err := range errs {
+		if err != nil {
+			e.indices = append(e.indices, i)
+			e.errs = append(e.errs, err)
+		}
+	}
+	if len(e.errs) == 0 {
+		return nil
+	}
+	return &e
+}
+
+// Errors returns the error of each failed element, keyed by the element's
+// index.
+func (e *IndexedErrors) Errors() map[int]error {
+	res := make(map[int]error, len(e.errs))
+	for i, idx := range e.indices {
+		res[idx] = e.errs[i]
+	}
+	return res
+}
+
+// Indices returns the indices of the failed elements, in ascending order.
+func (e *IndexedErrors) Indices() []int {
+	return append([]int(nil), e.indices...)
+}
+
+// Error formats the errors of the failed elements in input order, one per
+// line, like the result of errors.Join.
+func (e *IndexedErrors) Error() string {
+	var b strings.Builder
+	for i, err := range e.errs {
+		if i > 0 {
+			b.WriteByte('\n')
+		}
+		b.WriteString(err.Error())
+	}
+	return b.String()
+}
+
+func (e *IndexedErrors) Unwrap() []error {
+	return e.errs
+}
+
+// tryElement calls f, which processes the element at index i. With
+// PanicAsError, a panic from f is recovered and returned as an
+// *ElementError.
+func tryElement(policy PanicPolicy, i int, f func() error) error {
+	if policy != PanicAsError {
+		return f()
+	}
+
+	var err error
+	if recovered := panics.Try(func() { err = f() }); recovered != nil {
+		return &ElementError{Index: i, Err: recovered.AsError()}
+	}
+	return err
+}
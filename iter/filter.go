@@ -0,0 +1,158 @@
+package iter
+
+import (
+	"sync/atomic"
+)
+
+// Filter returns the elements of input for which f returns true, in the
+// same order as in input. f is called in parallel.
+//
+// Filter always uses at most runtime.GOMAXPROCS goroutines. For a
+// configurable goroutine limit, use a custom Iterator.
+func Filter[T any](input []T, f func(*T) bool) []T {
+	return Iterator[T]{}.Filter(input, f)
+}
+
+// Filter returns the elements of input for which f returns true, in the
+// same order as in input. f is called in parallel, using up to the
+// Iterator's configured maximum number of goroutines.
+func (iter Iterator[T]) Filter(input []T, f func(*T) bool) []T {
+	matched, _ := iter.partition(input, f, false)
+	return matched
+}
+
+// Partition splits input into the elements for which f returns true and the
+// ones for which it returns false. Both slices preserve the order of input.
+// f is called in parallel.
+//
+// Partition always uses at most runtime.GOMAXPROCS goroutines. For a
+// configurable goroutine limit, use a custom Iterator.
+func Partition[T any](input []T, f func(*T) bool) (matched, unmatched []T) {
+	return Iterator[T]{}.Partition(input, f)
+}
+
+// Partition splits input into the elements for which f returns true and the
+// ones for which it returns false. Both slices preserve the order of input.
+// f is called in parallel, using up to the Iterator's configured maximum
+// number of goroutines.
+func (iter Iterator[T]) Partition(input []T, f func(*T) bool) (matched, unmatched []T) {
+	return iter.partition(input, f, true)
+}
+
+func (iter Iterator[T]) partition(input []T, f func(*T) bool, keepUnmatched bool) (matched, unmatched []T) {
+	keep := make([]bool, len(input))
+	var numMatched atomic.Int64
+	iter.ForEachIdx(input, func(i int, t *T) {
+		if f(t) {
+			keep[i] = true
+			numMatched.Add(1)
+		}
+	})
+
+	matched = make([]T, 0, numMatched.Load())
+	if keepUnmatched {
+		unmatched = make([]T, 0, len(input)-len(matched))
+	}
+	for i, k := range keep {
+		if k {
+			matched = append(matched, input[i])
+		} else if keepUnmatched {
+			unmatched = append(unmatched, input[i])
+		}
+	}
+	return matched, unmatched
+}
+
+// Find returns the first element of input for which f returns true, and
+// whether such an element was found. f is called in parallel.
+//
+// Find short-circuits: once a match is found, no element after it is
+// started. Elements before it are still checked so that the first match in
+// input order is returned, rather than the first one to be found.
+//
+// Find always uses at most runtime.GOMAXPROCS goroutines. For a configurable
+// goroutine limit, use a custom Iterator.
+func Find[T any](input []T, f func(*T) bool) (T, bool) {
+	return Iterator[T]{}.Find(input, f)
+}
+
+// Find returns the first element of input for which f returns true, and
+// whether such an element was found. f is called in parallel, using up to
+// the Iterator's configured maximum number of goroutines.
+//
+// Find short-circuits: once a match is found, no element after it is
+// started. Elements before it are still checked so that the first match in
+// input order is returned, rather than the first one to be found.
+func (iter Iterator[T]) Find(input []T, f func(*T) bool) (T, bool) {
+	// first is the index of the first match found so far. Elements are
+	// claimed in increasing index order, so once a goroutine claims an index
+	// past first, it will never find an earlier match.
+	var first atomic.Int64
+	first.Store(int64(len(input)))
+
+	iter.forEachIdxWhile(input, func(i int, t *T) bool {
+		if int64(i) > first.Load() {
+			return false
+		}
+		if !f(t) {
+			return true
+		}
+		for {
+			cur := first.Load()
+			if int64(i) >= cur || first.CompareAndSwap(cur, int64(i)) {
+				return false
+			}
+		}
+	})
+
+	if i := int(first.Load()); i < len(input) {
+		return input[i], true
+	}
+	var zero T
+	return zero, false
+}
+
+// Any returns whether f returns true for any element of input. f is called
+// in parallel, and no more elements are started once a match is found.
+//
+// Any always uses at most runtime.GOMAXPROCS goroutines. For a configurable
+// goroutine limit, use a custom Iterator.
+func Any[T any](input []T, f func(*T) bool) bool {
+	return Iterator[T]{}.Any(input, f)
+}
+
+// Any returns whether f returns true for any element of input. f is called
+// in parallel, using up to the Iterator's configured maximum number of
+// goroutines, and no more elements are started once a match is found.
+func (iter Iterator[T]) Any(input []T, f func(*T) bool) bool {
+	var found atomic.Bool
+	iter.forEachIdxWhile(input, func(_ int, t *T) bool {
+		if found.Load() {
+			return false
+		}
+		if f(t) {
+			found.Store(true)
+			return false
+		}
+		return true
+	})
+	return found.Load()
+}
+
+// All returns whether f returns true for every element of input. f is called
+// in parallel, and no more elements are started once f returns false. All
+// returns true if input is empty.
+//
+// All always uses at most runtime.GOMAXPROCS goroutines. For a configurable
+// goroutine limit, use a custom Iterator.
+func All[T any](input []T, f func(*T) bool) bool {
+	return Iterator[T]{}.All(input, f)
+}
+
+// All returns whether f returns true for every element of input. f is called
+// in parallel, using up to the Iterator's configured maximum number of
+// goroutines, and no more elements are started once f returns false. All
+// returns true if input is empty.
+func (iter Iterator[T]) All(input []T, f func(*T) bool) bool {
+	return !iter.Any(input, func(t *T) bool { return !f(t) })
+}
@@ -0,0 +1,144 @@
+package iter_test
+
+import (
+	"fmt"
+	"sync/atomic"
+	"testing"
+
+	"github.com/sourcegraph/conc/iter"
+
+	"github.com/stretchr/testify/require"
+)
+
+func ExampleFilter() {
+	input := []int{1, 2, 3, 4, 5, 6}
+	evens := iter.Filter(input, func(v *int) bool { return *v%2 == 0 })
+	fmt.Println(evens)
+	// Output:
+	// [2 4 6]
+}
+
+func TestFilter(t *testing.T) {
+	t.Parallel()
+
+	isEven := func(v *int) bool { return *v%2 == 0 }
+
+	t.Run("empty", func(t *testing.T) {
+		t.Parallel()
+		res := iter.Filter([]int{}, func(*int) bool {
+			panic("this should never be called")
+		})
+		require.Empty(t, res)
+	})
+
+	t.Run("order is preserved", func(t *testing.T) {
+		t.Parallel()
+		ints := make([]int, 10000)
+		var expected []int
+		for i := range ints {
+			ints[i] = i
+			if i%2 == 0 {
+				expected = append(expected, i)
+			}
+		}
+		require.Equal(t, expected, iter.Filter(ints, isEven))
+	})
+
+	t.Run("partition", func(t *testing.T) {
+		t.Parallel()
+		matched, unmatched := iter.Partition([]int{1, 2, 3, 4, 5}, isEven)
+		require.Equal(t, []int{2, 4}, matched)
+		require.Equal(t, []int{1, 3, 5}, unmatched)
+	})
+
+	t.Run("panic is propagated", func(t *testing.T) {
+		t.Parallel()
+		f := func() {
+			iter.Filter([]int{1}, func(*int) bool {
+				panic("super bad thing happened")
+			})
+		}
+		require.Panics(t, f)
+	})
+}
+
+func TestFind(t *testing.T) {
+	t.Parallel()
+
+	t.Run("empty", func(t *testing.T) {
+		t.Parallel()
+		_, ok := iter.Find([]int{}, func(*int) bool {
+			panic("this should never be called")
+		})
+		require.False(t, ok)
+	})
+
+	t.Run("not found", func(t *testing.T) {
+		t.Parallel()
+		_, ok := iter.Find([]int{1, 2, 3}, func(v *int) bool { return *v > 3 })
+		require.False(t, ok)
+	})
+
+	t.Run("first match is returned", func(t *testing.T) {
+		t.Parallel()
+		ints := make([]int, 10000)
+		for i := range ints {
+			ints[i] = i
+		}
+		for i := 0; i < 100; i++ {
+			v, ok := iter.Find(ints, func(v *int) bool { return *v >= 5000 && *v%7 == 0 })
+			require.True(t, ok)
+			require.Equal(t, 5005, v)
+		}
+	})
+
+	t.Run("short circuits", func(t *testing.T) {
+		t.Parallel()
+		ints := make([]int, 10000)
+		var calls atomic.Int64
+		iterator := iter.Iterator[int]{MaxGoroutines: 4}
+		_, ok := iterator.Find(ints, func(*int) bool {
+			calls.Add(1)
+			return true
+		})
+		require.True(t, ok)
+		require.Less(t, calls.Load(), int64(10000))
+	})
+}
+
+func TestAnyAll(t *testing.T) {
+	t.Parallel()
+
+	isPositive := func(v *int) bool { return *v > 0 }
+
+	t.Run("empty", func(t *testing.T) {
+		t.Parallel()
+		require.False(t, iter.Any([]int{}, isPositive))
+		require.True(t, iter.All([]int{}, isPositive))
+	})
+
+	t.Run("any", func(t *testing.T) {
+		t.Parallel()
+		require.True(t, iter.Any([]int{-1, 0, 1}, isPositive))
+		require.False(t, iter.Any([]int{-1, 0}, isPositive))
+	})
+
+	t.Run("all", func(t *testing.T) {
+		t.Parallel()
+		require.True(t, iter.All([]int{1, 2, 3}, isPositive))
+		require.False(t, iter.All([]int{1, 0, 3}, isPositive))
+	})
+
+	t.Run("short circuits", func(t *testing.T) {
+		t.Parallel()
+		ints := make([]int, 10000)
+		var calls atomic.Int64
+		iterator := iter.Iterator[int]{MaxGoroutines: 4}
+		res := iterator.All(ints, func(*int) bool {
+			calls.Add(1)
+			return false
+		})
+		require.False(t, res)
+		require.Less(t, calls.Load(), int64(10000))
+	})
+}
@@ -1,7 +1,9 @@
 package iter
 
 import (
+	"context"
 	"runtime"
+	"sync"
 	"sync/atomic"
 
 	"github.com/sourcegraph/conc"
@@ -22,8 +24,53 @@ type Iterator[T any] struct {
 	//
 	// If unset, MaxGoroutines defaults to runtime.GOMAXPROCS(0).
 	MaxGoroutines int
+
+	// ChunkSize controls the number of consecutive elements a goroutine
+	// claims at once. Claiming elements in chunks reduces the per-element
+	// overhead, which matters when the callback is very cheap, at the cost
+	// of balancing the work between goroutines less evenly.
+	//
+	// If unset, ChunkSize defaults to 1, so elements are claimed one at a
+	// time, which is best for expensive or unevenly expensive elements. Set
+	// it to AutoChunkSize to have it chosen based on the length of the input
+	// and the number of goroutines.
+	ChunkSize int
+
+	// PanicPolicy controls what happens when a callback panics.
+	//
+	// If unset, PanicPolicy defaults to PanicContinue.
+	PanicPolicy PanicPolicy
 }
 
+// AutoChunkSize can be used as an Iterator's ChunkSize to have the chunk size
+// chosen based on the length of the input and the number of goroutines. It
+// suits cheap callbacks of roughly equal cost, where the overhead of claiming
+// elements one at a time would dominate.
+const AutoChunkSize = -1
+
+// PanicPolicy controls how an Iterator or a Mapper behaves when a callback
+// panics.
+type PanicPolicy int
+
+const (
+	// PanicContinue keeps processing the remaining elements after a panic,
+	// then propagates the panic once all goroutines are done. This is the
+	// default.
+	PanicContinue PanicPolicy = iota
+
+	// PanicStop stops claiming new elements as soon as a callback panics,
+	// then propagates the panic once all goroutines are done. Elements that
+	// were already claimed are still processed.
+	PanicStop
+
+	// PanicAsError recovers panics in the callbacks of functions that return
+	// an error, such as MapErr and ForEachCtx, and treats them as errors
+	// returned for that element. The error is an *ElementError wrapping a
+	// *panics.ErrRecovered. Functions that don't return an error behave as
+	// with PanicContinue.
+	PanicAsError
+)
+
 // ForEach executes f in parallel over each element in input.
 //
 // It is safe to mutate the input parameter, which makes it
@@ -57,24 +104,187 @@ func ForEachIdx[T any](input []T, f func(int, *T)) { Iterator[T]{}.ForEachIdx(in
 // ForEachIdx is the same as ForEach except it also provides the
 // index of the element to the callback.
 func (iter Iterator[T]) ForEachIdx(input []T, f func(int, *T)) {
+	iter.forEachIdxWhile(input, func(i int, t *T) bool {
+		f(i, t)
+		return true
+	})
+}
+
+// ForEachCtx executes f in parallel over each element in input, passing it
+// a context derived from ctx.
+//
+// As soon as ctx is canceled or f returns an error, the derived context is
+// canceled and no more elements are started. ForEachCtx returns the first
+// error returned by f, or ctx's error if ctx was canceled before every
+// element was started.
+//
+// ForEachCtx always uses at most runtime.GOMAXPROCS goroutines. For a
+// configurable goroutine limit, use a custom Iterator.
+func ForEachCtx[T any](ctx context.Context, input []T, f func(context.Context, *T) error) error {
+	return Iterator[T]{}.ForEachCtx(ctx, input, f)
+}
+
+// ForEachCtx executes f in parallel over each element in input, passing it
+// a context derived from ctx, using up to the Iterator's configured maximum
+// number of goroutines.
+//
+// As soon as ctx is canceled or f returns an error, the derived context is
+// canceled and no more elements are started. ForEachCtx returns the first
+// error returned by f, or ctx's error if ctx was canceled before every
+// element was started.
+func (iter Iterator[T]) ForEachCtx(ctx context.Context, input []T, f func(context.Context, *T) error) error {
+	return iter.ForEachIdxCtx(ctx, input, func(ctx context.Context, _ int, t *T) error {
+		return f(ctx, t)
+	})
+}
+
+// ForEachIdxCtx is the same as ForEachCtx except it also provides the
+// index of the element to the callback.
+func ForEachIdxCtx[T any](ctx context.Context, input []T, f func(context.Context, int, *T) error) error {
+	return Iterator[T]{}.ForEachIdxCtx(ctx, input, f)
+}
+
+// ForEachIdxCtx is the same as ForEachCtx except it also provides the
+// index of the element to the callback.
+func (iter Iterator[T]) ForEachIdxCtx(ctx context.Context, input []T, f func(context.Context, int, *T) error) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var (
+		errOnce  sync.Once
+		firstErr error
+	)
+	setErr := func(err error) {
+		errOnce.Do(func() {
+			firstErr = err
+			cancel()
+		})
+	}
+
+	iter.forEachIdxWhile(input, func(i int, t *T) bool {
+		if err := ctx.Err(); err != nil {
+			setErr(err)
+			return false
+		}
+		if err := tryElement(iter.PanicPolicy, i, func() error { return f(ctx, i, t) }); err != nil {
+			setErr(err)
+			return false
+		}
+		return true
+	})
+	return firstErr
+}
+
+// ForEachChunk calls f in parallel on consecutive, non-overlapping ranges
+// of indices of input, from start (inclusive) to end (exclusive), which
+// together cover the whole input. It is useful when the per-element work is
+// so small that even the overhead of ForEachIdx dominates.
+//
+// ForEachChunk always uses at most runtime.GOMAXPROCS goroutines, and
+// AutoChunkSize. For a configurable goroutine limit or chunk size, use a
+// custom Iterator.
+func ForEachChunk[T any](input []T, f func(start, end int)) {
+	Iterator[T]{ChunkSize: AutoChunkSize}.ForEachChunk(input, f)
+}
+
+// ForEachChunk calls f in parallel on consecutive, non-overlapping ranges
+// of indices of input, from start (inclusive) to end (exclusive), which
+// together cover the whole input. Each range has the Iterator's configured
+// ChunkSize, except for the last one which may be shorter.
+func (iter Iterator[T]) ForEachChunk(input []T, f func(start, end int)) {
+	iter.forEachChunkWhile(len(input), func(start, end int) bool {
+		f(start, end)
+		return true
+	})
+}
+
+// forEachIdxWhile is the same as ForEachIdx, except that a goroutine stops
+// claiming new elements as soon as f returns false. Other goroutines are
+// unaffected, so callers that want every goroutine to stop must make f
+// return false on every goroutine.
+func (iter Iterator[T]) forEachIdxWhile(input []T, f func(int, *T) bool) {
+	iter.forEachChunkWhile(len(input), func(start, end int) bool {
+		for i := start; i < end; i++ {
+			if !f(i, &input[i]) {
+				return false
+			}
+		}
+		return true
+	})
+}
+
+// forEachChunkWhile calls f in parallel on consecutive chunks of
+// [0, numInput). A goroutine stops claiming new chunks as soon as f returns
+// false.
+func (iter Iterator[T]) forEachChunkWhile(numInput int, f func(start, end int) bool) {
+	iter.forEachChunkWorker(numInput, func() func(start, end int) bool {
+		return f
+	})
+}
+
+// forEachChunkWorker is the same as forEachChunkWhile, except that each
+// goroutine calls newWorker once when it starts, and uses the returned
+// function for all of the chunks it claims.
+func (iter Iterator[T]) forEachChunkWorker(numInput int, newWorker func() func(start, end int) bool) {
 	if iter.MaxGoroutines == 0 {
 		// iter is a value receiver and is hence safe to mutate
 		iter.MaxGoroutines = defaultMaxGoroutines()
 	}
 
-	numInput := len(input)
-	if iter.MaxGoroutines > numInput {
-		// No more concurrent tasks than the number of input items.
-		iter.MaxGoroutines = numInput
+	if iter.ChunkSize < 0 {
+		iter.ChunkSize = autoChunkSize(numInput, iter.MaxGoroutines)
+	} else if iter.ChunkSize == 0 {
+		iter.ChunkSize = 1
+	}
+	if iter.ChunkSize > numInput {
+		// A single chunk covers the input. Clamping also keeps very large
+		// chunk sizes from overflowing below.
+		iter.ChunkSize = numInput
+		if iter.ChunkSize < 1 {
+			iter.ChunkSize = 1
+		}
+	}
+
+	numChunks := (numInput + iter.ChunkSize - 1) / iter.ChunkSize
+	if iter.MaxGoroutines > numChunks {
+		// No more concurrent tasks than the number of chunks.
+		iter.MaxGoroutines = numChunks
 	}
 
-	var idx atomic.Int64
+	chunkSize := int64(iter.ChunkSize)
+	stopOnPanic := iter.PanicPolicy == PanicStop
+	var (
+		idx      atomic.Int64
+		panicked atomic.Bool
+	)
+	work := func() {
+		f := newWorker()
+		start := int(idx.Add(chunkSize) - chunkSize)
+		for ; start < numInput; start = int(idx.Add(chunkSize) - chunkSize) {
+			end := start + int(chunkSize)
+			if end > numInput {
+				end = numInput
+			}
+			if !f(start, end) {
+				return
+			}
+			if stopOnPanic && panicked.Load() {
+				return
+			}
+		}
+	}
 	// Create the task outside the loop to avoid extra closure allocations.
 	task := func() {
-		i := int(idx.Add(1) - 1)
-		for ; i < numInput; i = int(idx.Add(1) - 1) {
-			f(i, &input[i])
-		}
+		completed := false
+		defer func() {
+			// work only fails to complete if f panicked. The panic itself is
+			// caught and propagated by the WaitGroup.
+			if !completed {
+				panicked.Store(true)
+			}
+		}()
+		work()
+		completed = true
 	}
 
 	var wg conc.WaitGroup
@@ -83,3 +293,29 @@ func (iter Iterator[T]) ForEachIdx(input []T, f func(int, *T)) {
 	}
 	wg.Wait()
 }
+
+const (
+	// chunksPerGoroutine is the number of chunks each goroutine should get
+	// with an automatic chunk size. More than one keeps the goroutines
+	// balanced when some elements are more expensive than others.
+	chunksPerGoroutine = 8
+
+	// maxAutoChunkSize caps the automatic chunk size. It is large enough to
+	// make the cost of claiming a chunk negligible for cheap callbacks, and
+	// small enough that a chunk of expensive callbacks doesn't leave the
+	// other goroutines idle for long.
+	maxAutoChunkSize = 64
+)
+
+// autoChunkSize picks a chunk size for an input of length numInput processed
+// by numGoroutines goroutines.
+func autoChunkSize(numInput, numGoroutines int) int {
+	size := numInput / (numGoroutines * chunksPerGoroutine)
+	if size < 1 {
+		return 1
+	}
+	if size > maxAutoChunkSize {
+		return maxAutoChunkSize
+	}
+	return size
+}
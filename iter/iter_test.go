@@ -1,12 +1,16 @@
 package iter_test
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"math"
 	"strconv"
 	"sync/atomic"
 	"testing"
 
 	"github.com/sourcegraph/conc/iter"
+	"github.com/sourcegraph/conc/panics"
 
 	"github.com/stretchr/testify/require"
 )
@@ -168,6 +172,233 @@ func TestForEach(t *testing.T) {
 	})
 }
 
+func TestForEachChunk(t *testing.T) {
+	t.Parallel()
+
+	t.Run("empty", func(t *testing.T) {
+		t.Parallel()
+		f := func() {
+			iter.ForEachChunk([]int{}, func(start, end int) {
+				panic("this should never be called")
+			})
+		}
+		require.NotPanics(t, f)
+	})
+
+	t.Run("chunks cover the input", func(t *testing.T) {
+		t.Parallel()
+		for _, chunkSize := range []int{iter.AutoChunkSize, 0, 1, 3, 64, 20000} {
+			ints := make([]int, 10000)
+			var badChunks atomic.Int64
+			maxLen := chunkSize
+			if maxLen == 0 {
+				maxLen = 1
+			}
+			iterator := iter.Iterator[int]{ChunkSize: chunkSize}
+			iterator.ForEachChunk(ints, func(start, end int) {
+				if start >= end || (maxLen > 0 && end-start > maxLen) {
+					badChunks.Add(1)
+				}
+				for i := start; i < end; i++ {
+					ints[i] += 1
+				}
+			})
+			require.Equal(t, int64(0), badChunks.Load())
+			for i := range ints {
+				require.Equal(t, 1, ints[i])
+			}
+		}
+	})
+
+	t.Run("huge chunk size", func(t *testing.T) {
+		t.Parallel()
+		ints := make([]int, 10)
+		iterator := iter.Iterator[int]{ChunkSize: math.MaxInt}
+		iterator.ForEach(ints, func(val *int) {
+			*val = 1
+		})
+		require.Equal(t, []int{1, 1, 1, 1, 1, 1, 1, 1, 1, 1}, ints)
+	})
+
+	t.Run("chunk size is used by ForEachIdx", func(t *testing.T) {
+		t.Parallel()
+		ints := make([]int, 1000)
+		iterator := iter.Iterator[int]{MaxGoroutines: 4, ChunkSize: 7}
+		iterator.ForEachIdx(ints, func(i int, val *int) {
+			*val = i
+		})
+		for i := range ints {
+			require.Equal(t, i, ints[i])
+		}
+	})
+
+	t.Run("default chunk size is one", func(t *testing.T) {
+		t.Parallel()
+		ints := make([]int, 1000)
+		var badChunks atomic.Int64
+		iterator := iter.Iterator[int]{}
+		iterator.ForEachChunk(ints, func(start, end int) {
+			if end-start != 1 {
+				badChunks.Add(1)
+			}
+		})
+		require.Equal(t, int64(0), badChunks.Load())
+	})
+
+	t.Run("chunk size is used by Mapper", func(t *testing.T) {
+		t.Parallel()
+		ints := []int{1, 2, 3, 4, 5}
+		mapper := iter.Mapper[int, int]{ChunkSize: 2}
+		res := mapper.Map(ints, func(val *int) int { return *val * 2 })
+		require.Equal(t, []int{2, 4, 6, 8, 10}, res)
+	})
+}
+
+func TestPanicPolicy(t *testing.T) {
+	t.Parallel()
+
+	t.Run("continue processes every element", func(t *testing.T) {
+		t.Parallel()
+		ints := make([]int, 100)
+		var calls atomic.Int64
+		iterator := iter.Iterator[int]{MaxGoroutines: 4, ChunkSize: 1}
+		require.Panics(t, func() {
+			iterator.ForEachIdx(ints, func(i int, _ *int) {
+				calls.Add(1)
+				if i == 0 {
+					panic("super bad thing happened")
+				}
+			})
+		})
+		require.Equal(t, int64(100), calls.Load())
+	})
+
+	t.Run("stop stops claiming new elements", func(t *testing.T) {
+		t.Parallel()
+		ints := make([]int, 10000)
+		var calls atomic.Int64
+		iterator := iter.Iterator[int]{
+			MaxGoroutines: 4,
+			ChunkSize:     1,
+			PanicPolicy:   iter.PanicStop,
+		}
+		require.Panics(t, func() {
+			iterator.ForEachIdx(ints, func(i int, _ *int) {
+				calls.Add(1)
+				if i == 0 {
+					panic("super bad thing happened")
+				}
+			})
+		})
+		require.Less(t, calls.Load(), int64(10000))
+	})
+
+	t.Run("as error in ForEachCtx", func(t *testing.T) {
+		t.Parallel()
+		iterator := iter.Iterator[int]{PanicPolicy: iter.PanicAsError}
+		err := iterator.ForEachIdxCtx(context.Background(), []int{1, 2, 3}, func(_ context.Context, i int, _ *int) error {
+			if i == 1 {
+				panic("super bad thing happened")
+			}
+			return nil
+		})
+
+		var elemErr *iter.ElementError
+		require.ErrorAs(t, err, &elemErr)
+		require.Equal(t, 1, elemErr.Index)
+
+		var recovered *panics.ErrRecovered
+		require.ErrorAs(t, err, &recovered)
+		require.Equal(t, "super bad thing happened", recovered.Value)
+	})
+
+	t.Run("as error still panics without error return", func(t *testing.T) {
+		t.Parallel()
+		iterator := iter.Iterator[int]{PanicPolicy: iter.PanicAsError}
+		require.Panics(t, func() {
+			iterator.ForEach([]int{1}, func(*int) {
+				panic("super bad thing happened")
+			})
+		})
+	})
+}
+
+func TestForEachCtx(t *testing.T) {
+	t.Parallel()
+
+	bgctx := context.Background()
+	err1 := errors.New("err1")
+
+	t.Run("empty", func(t *testing.T) {
+		t.Parallel()
+		err := iter.ForEachCtx(bgctx, []int{}, func(context.Context, *int) error {
+			panic("this should never be called")
+		})
+		require.NoError(t, err)
+	})
+
+	t.Run("mutating inputs is fine", func(t *testing.T) {
+		t.Parallel()
+		ints := []int{1, 2, 3, 4, 5}
+		err := iter.ForEachCtx(bgctx, ints, func(_ context.Context, val *int) error {
+			*val += 1
+			return nil
+		})
+		require.NoError(t, err)
+		require.Equal(t, []int{2, 3, 4, 5, 6}, ints)
+	})
+
+	t.Run("error stops claiming new elements", func(t *testing.T) {
+		t.Parallel()
+		ints := make([]int, 10000)
+		var calls atomic.Int64
+		iterator := iter.Iterator[int]{MaxGoroutines: 4}
+		err := iterator.ForEachIdxCtx(bgctx, ints, func(ctx context.Context, i int, val *int) error {
+			calls.Add(1)
+			if i == 0 {
+				return err1
+			}
+			return nil
+		})
+		require.ErrorIs(t, err, err1)
+		require.Less(t, calls.Load(), int64(10000))
+	})
+
+	t.Run("error cancels context", func(t *testing.T) {
+		t.Parallel()
+		ints := []int{0, 1}
+		iterator := iter.Iterator[int]{MaxGoroutines: 2}
+		err := iterator.ForEachCtx(bgctx, ints, func(ctx context.Context, val *int) error {
+			if *val == 0 {
+				return err1
+			}
+			<-ctx.Done()
+			return ctx.Err()
+		})
+		require.ErrorIs(t, err, err1)
+	})
+
+	t.Run("canceled context", func(t *testing.T) {
+		t.Parallel()
+		ctx, cancel := context.WithCancel(bgctx)
+		cancel()
+		err := iter.ForEachCtx(ctx, []int{1, 2, 3}, func(context.Context, *int) error {
+			panic("this should never be called")
+		})
+		require.ErrorIs(t, err, context.Canceled)
+	})
+
+	t.Run("panic is propagated", func(t *testing.T) {
+		t.Parallel()
+		f := func() {
+			_ = iter.ForEachCtx(bgctx, []int{1}, func(context.Context, *int) error {
+				panic("super bad thing happened")
+			})
+		}
+		require.Panics(t, f)
+	})
+}
+
 func BenchmarkForEach(b *testing.B) {
 	for _, count := range []int{0, 1, 8, 100, 1000, 10000, 100000} {
 		b.Run(strconv.Itoa(count), func(b *testing.B) {
@@ -180,3 +411,18 @@ func BenchmarkForEach(b *testing.B) {
 		})
 	}
 }
+
+func BenchmarkForEachChunk(b *testing.B) {
+	for _, count := range []int{0, 1, 8, 100, 1000, 10000, 100000} {
+		b.Run(strconv.Itoa(count), func(b *testing.B) {
+			ints := make([]int, count)
+			for i := 0; i < b.N; i++ {
+				iter.ForEachChunk(ints, func(start, end int) {
+					for i := start; i < end; i++ {
+						ints[i] = 0
+					}
+				})
+			}
+		})
+	}
+}
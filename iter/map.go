@@ -1,8 +1,7 @@
 package iter
 
 import (
-	"errors"
-	"sync"
+	"context"
 )
 
 // Mapper is an Iterator with a result type R. It can be used to configure
@@ -34,6 +33,9 @@ func (m Mapper[T, R]) Map(input []T, f func(*T) R) []R {
 // MapErr applies f to each element of the input, returning the mapped result
 // and a combined error of all returned errors.
 //
+// If f returned an error for any element, the error is an *IndexedErrors,
+// which records the indices of the elements that failed.
+//
 // Map always uses at most runtime.GOMAXPROCS goroutines. For a configurable
 // goroutine limit, use a custom Mapper.
 func MapErr[T, R any](input []T, f func(*T) (R, error)) ([]R, error) {
@@ -43,21 +45,150 @@ func MapErr[T, R any](input []T, f func(*T) (R, error)) ([]R, error) {
 // MapErr applies f to each element of the input, returning the mapped result
 // and a combined error of all returned errors.
 //
+// If f returned an error for any element, the error is an *IndexedErrors,
+// which records the indices of the elements that failed.
+//
 // Map uses up to the configured Mapper's maximum number of goroutines.
 func (m Mapper[T, R]) MapErr(input []T, f func(*T) (R, error)) ([]R, error) {
 	var (
-		res    = make([]R, len(input))
-		errMux sync.Mutex
-		errs   []error
+		res  = make([]R, len(input))
+		errs = make([]error, len(input))
 	)
 	Iterator[T](m).ForEachIdx(input, func(i int, t *T) {
+		errs[i] = tryElement(m.PanicPolicy, i, func() (err error) {
+			res[i], err = f(t)
+			return err
+		})
+	})
+	return res, newIndexedErrors(errs)
+}
+
+// MapCtx applies f to each element of input, passing it a context derived
+// from ctx, and returns the mapped result.
+//
+// As soon as ctx is canceled, no more elements are started, and MapCtx
+// returns ctx's error along with the partially mapped result.
+//
+// MapCtx always uses at most runtime.GOMAXPROCS goroutines. For a
+// configurable goroutine limit, use a custom Mapper.
+func MapCtx[T, R any](ctx context.Context, input []T, f func(context.Context, *T) R) ([]R, error) {
+	return Mapper[T, R]{}.MapCtx(ctx, input, f)
+}
+
+// MapCtx applies f to each element of input, passing it a context derived
+// from ctx, and returns the mapped result.
+//
+// As soon as ctx is canceled, no more elements are started, and MapCtx
+// returns ctx's error along with the partially mapped result.
+//
+// MapCtx uses up to the configured Mapper's maximum number of goroutines.
+func (m Mapper[T, R]) MapCtx(ctx context.Context, input []T, f func(context.Context, *T) R) ([]R, error) {
+	res := make([]R, len(input))
+	err := Iterator[T](m).ForEachIdxCtx(ctx, input, func(ctx context.Context, i int, t *T) error {
+		res[i] = f(ctx, t)
+		return nil
+	})
+	return res, err
+}
+
+// MapErrCtx applies f to each element of input, passing it a context derived
+// from ctx, and returns the mapped result.
+//
+// Unlike MapErr, MapErrCtx fails fast: as soon as ctx is canceled or f
+// returns an error, the derived context is canceled and no more elements are
+// started. MapErrCtx returns the partially mapped result along with the first
+// error returned by f, or ctx's error if ctx was canceled.
+//
+// MapErrCtx always uses at most runtime.GOMAXPROCS goroutines. For a
+// configurable goroutine limit, use a custom Mapper.
+func MapErrCtx[T, R any](ctx context.Context, input []T, f func(context.Context, *T) (R, error)) ([]R, error) {
+	return Mapper[T, R]{}.MapErrCtx(ctx, input, f)
+}
+
+// MapErrCtx applies f to each element of input, passing it a context derived
+// from ctx, and returns the mapped result.
+//
+// Unlike MapErr, MapErrCtx fails fast: as soon as ctx is canceled or f
+// returns an error, the derived context is canceled and no more elements are
+// started. MapErrCtx returns the partially mapped result along with the first
+// error returned by f, or ctx's error if ctx was canceled.
+//
+// MapErrCtx uses up to the configured Mapper's maximum number of goroutines.
+func (m Mapper[T, R]) MapErrCtx(ctx context.Context, input []T, f func(context.Context, *T) (R, error)) ([]R, error) {
+	res := make([]R, len(input))
+	err := Iterator[T](m).ForEachIdxCtx(ctx, input, func(ctx context.Context, i int, t *T) error {
 		var err error
-		res[i], err = f(t)
-		if err != nil {
-			errMux.Lock()
-			errs = append(errs, err)
-			errMux.Unlock()
-		}
+		res[i], err = f(ctx, t)
+		return err
+	})
+	return res, err
+}
+
+// FlatMap applies f to each element of input, returning the concatenation of
+// the returned slices in input order.
+//
+// The results of f are copied into a single slice that is allocated once the
+// total length is known, in parallel.
+//
+// FlatMap always uses at most runtime.GOMAXPROCS goroutines. For a
+// configurable goroutine limit, use a custom Mapper.
+func FlatMap[T, R any](input []T, f func(*T) []R) []R {
+	return Mapper[T, R]{}.FlatMap(input, f)
+}
+
+// FlatMap applies f to each element of input, returning the concatenation of
+// the returned slices in input order.
+//
+// FlatMap uses up to the configured Mapper's maximum number of goroutines.
+func (m Mapper[T, R]) FlatMap(input []T, f func(*T) []R) []R {
+	parts := make([][]R, len(input))
+	Iterator[T](m).ForEachIdx(input, func(i int, t *T) {
+		parts[i] = f(t)
 	})
-	return res, errors.Join(errs...)
+	return m.flatten(parts)
+}
+
+// FlatMapErr applies f to each element of input, returning the concatenation
+// of the returned slices in input order, and a combined error of all
+// returned errors. Like with MapErr, the error is an *IndexedErrors.
+//
+// FlatMapErr always uses at most runtime.GOMAXPROCS goroutines. For a
+// configurable goroutine limit, use a custom Mapper.
+func FlatMapErr[T, R any](input []T, f func(*T) ([]R, error)) ([]R, error) {
+	return Mapper[T, R]{}.FlatMapErr(input, f)
+}
+
+// FlatMapErr applies f to each element of input, returning the concatenation
+// of the returned slices in input order, and a combined error of all
+// returned errors. Like with MapErr, the error is an *IndexedErrors.
+//
+// FlatMapErr uses up to the configured Mapper's maximum number of goroutines.
+func (m Mapper[T, R]) FlatMapErr(input []T, f func(*T) ([]R, error)) ([]R, error) {
+	var (
+		parts = make([][]R, len(input))
+		errs  = make([]error, len(input))
+	)
+	Iterator[T](m).ForEachIdx(input, func(i int, t *T) {
+		errs[i] = tryElement(m.PanicPolicy, i, func() (err error) {
+			parts[i], err = f(t)
+			return err
+		})
+	})
+	return m.flatten(parts), newIndexedErrors(errs)
+}
+
+// flatten concatenates parts into a single slice.
+func (m Mapper[T, R]) flatten(parts [][]R) []R {
+	offsets := make([]int, len(parts))
+	total := 0
+	for i, part := range parts {
+		offsets[i] = total
+		total += len(part)
+	}
+
+	res := make([]R, total)
+	Iterator[[]R]{MaxGoroutines: m.MaxGoroutines}.ForEachIdx(parts, func(i int, part *[]R) {
+		copy(res[offsets[i]:], *part)
+	})
+	return res
 }
@@ -1,6 +1,7 @@
 package iter_test
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"testing"
@@ -179,4 +180,190 @@ func TestMapErr(t *testing.T) {
 		}
 		require.Equal(t, expected, res)
 	})
+
+	t.Run("errors are indexed", func(t *testing.T) {
+		t.Parallel()
+		ints := make([]int, 1000)
+		for i := range ints {
+			ints[i] = i
+		}
+		res, err := iter.MapErr(ints, func(val *int) (int, error) {
+			if *val%100 == 1 {
+				return 0, fmt.Errorf("failed %d", *val)
+			}
+			return *val, nil
+		})
+
+		var indexed *iter.IndexedErrors
+		require.ErrorAs(t, err, &indexed)
+		require.Equal(t, []int{1, 101, 201, 301, 401, 501, 601, 701, 801, 901}, indexed.Indices())
+		require.Len(t, indexed.Errors(), 10)
+		require.EqualError(t, indexed.Errors()[301], "failed 301")
+		require.Equal(t, 0, res[301])
+		require.Equal(t, 302, res[302])
+
+		// Errors are reported in input order.
+		require.EqualError(t, err, "failed 1\nfailed 101\nfailed 201\nfailed 301\nfailed 401\nfailed 501\nfailed 601\nfailed 701\nfailed 801\nfailed 901")
+	})
+
+	t.Run("no error is nil", func(t *testing.T) {
+		t.Parallel()
+		_, err := iter.MapErr([]int{1}, func(val *int) (int, error) {
+			return *val, nil
+		})
+		require.Nil(t, err)
+	})
+
+	t.Run("panic as error", func(t *testing.T) {
+		t.Parallel()
+		ints := []int{1, 2, 3, 4, 5}
+		mapper := iter.Mapper[int, int]{PanicPolicy: iter.PanicAsError}
+		res, err := mapper.MapErr(ints, func(val *int) (int, error) {
+			if *val == 4 {
+				panic(err1)
+			}
+			return *val + 1, nil
+		})
+		require.ErrorIs(t, err, err1)
+
+		var elemErr *iter.ElementError
+		require.ErrorAs(t, err, &elemErr)
+		require.Equal(t, 3, elemErr.Index)
+		require.Equal(t, []int{2, 3, 4, 0, 6}, res)
+	})
+}
+
+func TestMapCtx(t *testing.T) {
+	t.Parallel()
+
+	bgctx := context.Background()
+
+	t.Run("basic increment", func(t *testing.T) {
+		t.Parallel()
+		ints := []int{1, 2, 3, 4, 5}
+		res, err := iter.MapCtx(bgctx, ints, func(_ context.Context, val *int) int {
+			return *val + 1
+		})
+		require.NoError(t, err)
+		require.Equal(t, []int{2, 3, 4, 5, 6}, res)
+	})
+
+	t.Run("canceled context", func(t *testing.T) {
+		t.Parallel()
+		ctx, cancel := context.WithCancel(bgctx)
+		cancel()
+		res, err := iter.MapCtx(ctx, []int{1, 2, 3}, func(context.Context, *int) int {
+			panic("this should never be called")
+		})
+		require.ErrorIs(t, err, context.Canceled)
+		require.Equal(t, []int{0, 0, 0}, res)
+	})
+}
+
+func TestMapErrCtx(t *testing.T) {
+	t.Parallel()
+
+	bgctx := context.Background()
+	err1 := errors.New("err1")
+
+	t.Run("basic increment", func(t *testing.T) {
+		t.Parallel()
+		ints := []int{1, 2, 3, 4, 5}
+		res, err := iter.MapErrCtx(bgctx, ints, func(_ context.Context, val *int) (int, error) {
+			return *val + 1, nil
+		})
+		require.NoError(t, err)
+		require.Equal(t, []int{2, 3, 4, 5, 6}, res)
+	})
+
+	t.Run("first error is returned", func(t *testing.T) {
+		t.Parallel()
+		mapper := iter.Mapper[int, int]{MaxGoroutines: 1}
+		res, err := mapper.MapErrCtx(bgctx, []int{1, 2, 3, 4, 5}, func(_ context.Context, val *int) (int, error) {
+			if *val == 3 {
+				return 0, err1
+			}
+			return *val + 1, nil
+		})
+		require.ErrorIs(t, err, err1)
+		require.Equal(t, []int{2, 3, 0, 0, 0}, res)
+	})
+}
+
+func TestFlatMap(t *testing.T) {
+	t.Parallel()
+
+	repeat := func(val *int) []int {
+		res := make([]int, *val)
+		for i := range res {
+			res[i] = *val
+		}
+		return res
+	}
+
+	t.Run("empty", func(t *testing.T) {
+		t.Parallel()
+		res := iter.FlatMap([]int{}, func(*int) []int {
+			panic("this should never be called")
+		})
+		require.Empty(t, res)
+	})
+
+	t.Run("order is preserved", func(t *testing.T) {
+		t.Parallel()
+		res := iter.FlatMap([]int{1, 0, 3, 2}, repeat)
+		require.Equal(t, []int{1, 3, 3, 3, 2, 2}, res)
+	})
+
+	t.Run("huge inputs", func(t *testing.T) {
+		t.Parallel()
+		ints := make([]int, 10000)
+		var expected []int
+		for i := range ints {
+			ints[i] = i % 5
+			expected = append(expected, repeat(&ints[i])...)
+		}
+		require.Equal(t, expected, iter.FlatMap(ints, repeat))
+	})
+
+	t.Run("panic is propagated", func(t *testing.T) {
+		t.Parallel()
+		f := func() {
+			iter.FlatMap([]int{1}, func(*int) []int {
+				panic("super bad thing happened")
+			})
+		}
+		require.Panics(t, f)
+	})
+}
+
+func TestFlatMapErr(t *testing.T) {
+	t.Parallel()
+
+	err1 := errors.New("err1")
+
+	t.Run("basic", func(t *testing.T) {
+		t.Parallel()
+		res, err := iter.FlatMapErr([]int{1, 2}, func(val *int) ([]int, error) {
+			return []int{*val, *val}, nil
+		})
+		require.NoError(t, err)
+		require.Equal(t, []int{1, 1, 2, 2}, res)
+	})
+
+	t.Run("error is propagated", func(t *testing.T) {
+		t.Parallel()
+		res, err := iter.FlatMapErr([]int{1, 2, 3}, func(val *int) ([]int, error) {
+			if *val == 2 {
+				return nil, err1
+			}
+			return []int{*val}, nil
+		})
+		require.ErrorIs(t, err, err1)
+		require.Equal(t, []int{1, 3}, res)
+
+		var indexed *iter.IndexedErrors
+		require.ErrorAs(t, err, &indexed)
+		require.Equal(t, []int{1}, indexed.Indices())
+	})
 }
@@ -0,0 +1,84 @@
+package iter
+
+// ForEachMap executes f in parallel over each key-value pair of m.
+//
+// ForEachMap always uses at most runtime.GOMAXPROCS goroutines. For a
+// configurable goroutine limit, use ForEachMapWith with a custom Iterator.
+func ForEachMap[K comparable, V any](m map[K]V, f func(K, V)) {
+	ForEachMapWith(Iterator[V]{}, m, f)
+}
+
+// ForEachMapWith executes f in parallel over each key-value pair of m, using
+// up to the Iterator's configured maximum number of goroutines.
+func ForEachMapWith[K comparable, V any](iter Iterator[V], m map[K]V, f func(K, V)) {
+	entries := mapEntries(m)
+	Iterator[mapEntry[K, V]](iter).ForEach(entries, func(e *mapEntry[K, V]) {
+		f(e.key, e.value)
+	})
+}
+
+// MapValues applies f to each key-value pair of m in parallel, returning a
+// new map with the same keys and the mapped values.
+//
+// The results are collected without locking, and the returned map is built
+// once all calls to f have returned.
+//
+// MapValues always uses at most runtime.GOMAXPROCS goroutines. For a
+// configurable goroutine limit, use MapValuesWith with a custom Mapper.
+func MapValues[K comparable, V, R any](m map[K]V, f func(K, V) R) map[K]R {
+	return MapValuesWith(Mapper[V, R]{}, m, f)
+}
+
+// MapValuesWith applies f to each key-value pair of m in parallel, returning
+// a new map with the same keys and the mapped values. It uses up to the
+// configured Mapper's maximum number of goroutines.
+func MapValuesWith[K comparable, V, R any](mapper Mapper[V, R], m map[K]V, f func(K, V) R) map[K]R {
+	entries := mapEntries(m)
+	results := make([]R, len(entries))
+	Iterator[mapEntry[K, V]](mapper).ForEachIdx(entries, func(i int, e *mapEntry[K, V]) {
+		results[i] = f(e.key, e.value)
+	})
+
+	res := make(map[K]R, len(entries))
+	for i, e := range entries {
+		res[e.key] = results[i]
+	}
+	return res
+}
+
+// MapToSlice applies f to each key-value pair of m in parallel, returning a
+// slice of the mapped values. Like iteration over a map, the order of the
+// returned slice is unspecified.
+//
+// MapToSlice always uses at most runtime.GOMAXPROCS goroutines. For a
+// configurable goroutine limit, use MapToSliceWith with a custom Mapper.
+func MapToSlice[K comparable, V, R any](m map[K]V, f func(K, V) R) []R {
+	return MapToSliceWith(Mapper[V, R]{}, m, f)
+}
+
+// MapToSliceWith applies f to each key-value pair of m in parallel, returning
+// a slice of the mapped values in an unspecified order. It uses up to the
+// configured Mapper's maximum number of goroutines.
+func MapToSliceWith[K comparable, V, R any](mapper Mapper[V, R], m map[K]V, f func(K, V) R) []R {
+	entries := mapEntries(m)
+	res := make([]R, len(entries))
+	Iterator[mapEntry[K, V]](mapper).ForEachIdx(entries, func(i int, e *mapEntry[K, V]) {
+		res[i] = f(e.key, e.value)
+	})
+	return res
+}
+
+type mapEntry[K comparable, V any] struct {
+	key   K
+	value V
+}
+
+// mapEntries copies the key-value pairs of m into a slice so that they can
+// be claimed by index.
+func mapEntries[K comparable, V any](m map[K]V) []mapEntry[K, V] {
+	entries := make([]mapEntry[K, V], 0, len(m))
+	for k, v := range m {
+		entries = append(entries, mapEntry[K, V]{key: k, value: v})
+	}
+	return entries
+}
@@ -0,0 +1,137 @@
+package iter_test
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/sourcegraph/conc/iter"
+
+	"github.com/stretchr/testify/require"
+)
+
+func ExampleMapValues() {
+	input := map[string]int{"a": 1, "b": 2, "c": 3}
+	res := iter.MapValues(input, func(k string, v int) string {
+		return k + strconv.Itoa(v)
+	})
+	fmt.Println(res)
+	// Output:
+	// map[a:a1 b:b2 c:c3]
+}
+
+func TestForEachMap(t *testing.T) {
+	t.Parallel()
+
+	t.Run("empty", func(t *testing.T) {
+		t.Parallel()
+		f := func() {
+			iter.ForEachMap(map[int]int{}, func(int, int) {
+				panic("this should never be called")
+			})
+		}
+		require.NotPanics(t, f)
+	})
+
+	t.Run("every pair is visited", func(t *testing.T) {
+		t.Parallel()
+		input := make(map[int]int, 1000)
+		for i := 0; i < 1000; i++ {
+			input[i] = i * 2
+		}
+		var mu sync.Mutex
+		got := make(map[int]int, 1000)
+		iter.ForEachMap(input, func(k, v int) {
+			mu.Lock()
+			got[k] = v
+			mu.Unlock()
+		})
+		require.Equal(t, input, got)
+	})
+
+	t.Run("limit", func(t *testing.T) {
+		t.Parallel()
+		input := make(map[int]int, 100)
+		for i := 0; i < 100; i++ {
+			input[i] = i
+		}
+		var current, errCount atomic.Int64
+		iterator := iter.Iterator[int]{MaxGoroutines: 3}
+		iter.ForEachMapWith(iterator, input, func(int, int) {
+			if current.Add(1) > 3 {
+				errCount.Add(1)
+			}
+			time.Sleep(time.Millisecond)
+			current.Add(-1)
+		})
+		require.Equal(t, int64(0), errCount.Load())
+	})
+
+	t.Run("panic is propagated", func(t *testing.T) {
+		t.Parallel()
+		f := func() {
+			iter.ForEachMap(map[int]int{1: 1}, func(int, int) {
+				panic("super bad thing happened")
+			})
+		}
+		require.Panics(t, f)
+	})
+}
+
+func TestMapValues(t *testing.T) {
+	t.Parallel()
+
+	t.Run("empty", func(t *testing.T) {
+		t.Parallel()
+		res := iter.MapValues(map[int]int{}, func(int, int) int {
+			panic("this should never be called")
+		})
+		require.Empty(t, res)
+	})
+
+	t.Run("huge inputs", func(t *testing.T) {
+		t.Parallel()
+		input := make(map[int]int, 10000)
+		expected := make(map[int]string, 10000)
+		for i := 0; i < 10000; i++ {
+			input[i] = i
+			expected[i] = strconv.Itoa(i + 1)
+		}
+		res := iter.MapValues(input, func(_, v int) string {
+			return strconv.Itoa(v + 1)
+		})
+		require.Equal(t, expected, res)
+	})
+
+	t.Run("custom mapper", func(t *testing.T) {
+		t.Parallel()
+		input := map[string]int{"a": 1, "b": 2}
+		mapper := iter.Mapper[int, int]{MaxGoroutines: 1}
+		res := iter.MapValuesWith(mapper, input, func(_ string, v int) int {
+			return v * 10
+		})
+		require.Equal(t, map[string]int{"a": 10, "b": 20}, res)
+	})
+}
+
+func TestMapToSlice(t *testing.T) {
+	t.Parallel()
+
+	input := map[string]int{"a": 1, "b": 2, "c": 3}
+	concat := func(k string, v int) string {
+		return k + strconv.Itoa(v)
+	}
+
+	res := iter.MapToSlice(input, concat)
+	sort.Strings(res)
+	require.Equal(t, []string{"a1", "b2", "c3"}, res)
+
+	mapper := iter.Mapper[int, string]{MaxGoroutines: 2}
+	res = iter.MapToSliceWith(mapper, input, concat)
+	sort.Strings(res)
+	require.Equal(t, []string{"a1", "b2", "c3"}, res)
+}
@@ -0,0 +1,165 @@
+package iter
+
+import (
+	"github.com/sourcegraph/conc"
+)
+
+// Reduce combines the elements of input into a single value with f, in
+// parallel. It returns the zero value if input is empty.
+//
+// The input is split into contiguous partitions, one per goroutine. Each
+// partition is reduced locally, then the partial results are combined in
+// input order. f must therefore be associative, but it does not need to be
+// commutative: Reduce gives the same result as a sequential left fold.
+//
+// Reduce always uses at most runtime.GOMAXPROCS goroutines. For a
+// configurable goroutine limit, use a custom Iterator.
+func Reduce[T any](input []T, f func(T, T) T) T {
+	return Iterator[T]{}.Reduce(input, f)
+}
+
+// Reduce combines the elements of input into a single value with f, in
+// parallel, using up to the Iterator's configured maximum number of
+// goroutines. It returns the zero value if input is empty.
+//
+// The input is split into contiguous partitions, one per goroutine. Each
+// partition is reduced locally, then the partial results are combined in
+// input order. f must therefore be associative, but it does not need to be
+// commutative: Reduce gives the same result as a sequential left fold.
+func (iter Iterator[T]) Reduce(input []T, f func(T, T) T) T {
+	numParts := iter.numPartitions(len(input))
+	partials := make([]T, numParts)
+	forEachPartition(len(input), numParts, func(part, start, end int) {
+		acc := input[start]
+		for i := start + 1; i < end; i++ {
+			acc = f(acc, input[i])
+		}
+		partials[part] = acc
+	})
+
+	var res T
+	for i, partial := range partials {
+		if i == 0 {
+			res = partial
+		} else {
+			res = f(res, partial)
+		}
+	}
+	return res
+}
+
+// MapReduce applies mapper to each element of input, and combines the mapped
+// results into a single value with reducer, in parallel. It returns the zero
+// value if input is empty.
+//
+// Like Reduce, reducer must be associative, but it does not need to be
+// commutative.
+//
+// MapReduce always uses at most runtime.GOMAXPROCS goroutines. For a
+// configurable goroutine limit, use a custom Mapper.
+func MapReduce[T, R any](input []T, mapper func(*T) R, reducer func(R, R) R) R {
+	return Mapper[T, R]{}.MapReduce(input, mapper, reducer)
+}
+
+// MapReduce applies mapper to each element of input, and combines the mapped
+// results into a single value with reducer, in parallel. It returns the zero
+// value if input is empty.
+//
+// Like Reduce, reducer must be associative, but it does not need to be
+// commutative.
+//
+// MapReduce uses up to the configured Mapper's maximum number of goroutines.
+func (m Mapper[T, R]) MapReduce(input []T, mapper func(*T) R, reducer func(R, R) R) R {
+	numParts := Iterator[T](m).numPartitions(len(input))
+	partials := make([]R, numParts)
+	forEachPartition(len(input), numParts, func(part, start, end int) {
+		acc := mapper(&input[start])
+		for i := start + 1; i < end; i++ {
+			acc = reducer(acc, mapper(&input[i]))
+		}
+		partials[part] = acc
+	})
+
+	var res R
+	for i, partial := range partials {
+		if i == 0 {
+			res = partial
+		} else {
+			res = reducer(res, partial)
+		}
+	}
+	return res
+}
+
+// Fold accumulates the elements of input into a value of type R, in
+// parallel.
+//
+// Each goroutine starts an accumulator with identity, and adds the elements
+// of a contiguous partition of the input to it with fold. The accumulators
+// are then merged in input order with combine. combine must be associative,
+// and identity must return an identity element for it. Fold returns
+// identity() if input is empty.
+//
+// Unlike Reduce, the accumulator can have a different type than the
+// elements, which makes it possible to, for example, count elements into a
+// map without locking.
+//
+// Fold always uses at most runtime.GOMAXPROCS goroutines. For a configurable
+// goroutine limit, use a custom Mapper.
+func Fold[T, R any](input []T, identity func() R, fold func(R, *T) R, combine func(R, R) R) R {
+	return Mapper[T, R]{}.Fold(input, identity, fold, combine)
+}
+
+// Fold accumulates the elements of input into a value of type R, in
+// parallel, using up to the configured Mapper's maximum number of goroutines.
+//
+// Each goroutine starts an accumulator with identity, and adds the elements
+// of a contiguous partition of the input to it with fold. The accumulators
+// are then merged in input order with combine. combine must be associative,
+// and identity must return an identity element for it. Fold returns
+// identity() if input is empty.
+func (m Mapper[T, R]) Fold(input []T, identity func() R, fold func(R, *T) R, combine func(R, R) R) R {
+	numParts := Iterator[T](m).numPartitions(len(input))
+	partials := make([]R, numParts)
+	forEachPartition(len(input), numParts, func(part, start, end int) {
+		acc := identity()
+		for i := start; i < end; i++ {
+			acc = fold(acc, &input[i])
+		}
+		partials[part] = acc
+	})
+
+	res := identity()
+	for _, partial := range partials {
+		res = combine(res, partial)
+	}
+	return res
+}
+
+// numPartitions returns the number of partitions to split an input of
+// length numInput into, which is one per goroutine.
+func (iter Iterator[T]) numPartitions(numInput int) int {
+	if iter.MaxGoroutines == 0 {
+		iter.MaxGoroutines = defaultMaxGoroutines()
+	}
+	if iter.MaxGoroutines > numInput {
+		return numInput
+	}
+	return iter.MaxGoroutines
+}
+
+// forEachPartition splits [0, numInput) into numParts contiguous, non-empty
+// partitions of roughly equal size, and calls f on each one in its own
+// goroutine.
+func forEachPartition(numInput, numParts int, f func(part, start, end int)) {
+	var wg conc.WaitGroup
+	for part := 0; part < numParts; part++ {
+		part := part
+		start := part * numInput / numParts
+		end := (part + 1) * numInput / numParts
+		wg.Go(func() {
+			f(part, start, end)
+		})
+	}
+	wg.Wait()
+}
@@ -0,0 +1,153 @@
+package iter_test
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/sourcegraph/conc/iter"
+
+	"github.com/stretchr/testify/require"
+)
+
+func ExampleReduce() {
+	input := []int{1, 2, 3, 4, 5}
+	sum := iter.Reduce(input, func(a, b int) int { return a + b })
+	fmt.Println(sum)
+	// Output:
+	// 15
+}
+
+func ExampleFold() {
+	input := []string{"a", "b", "a", "c", "a"}
+	counts := iter.Fold(input,
+		func() map[string]int { return map[string]int{} },
+		func(acc map[string]int, s *string) map[string]int {
+			acc[*s]++
+			return acc
+		},
+		func(a, b map[string]int) map[string]int {
+			for k, v := range b {
+				a[k] += v
+			}
+			return a
+		},
+	)
+	fmt.Println(counts)
+	// Output:
+	// map[a:3 b:1 c:1]
+}
+
+func TestReduce(t *testing.T) {
+	t.Parallel()
+
+	add := func(a, b int) int { return a + b }
+
+	t.Run("empty", func(t *testing.T) {
+		t.Parallel()
+		res := iter.Reduce([]int{}, func(int, int) int {
+			panic("this should never be called")
+		})
+		require.Equal(t, 0, res)
+	})
+
+	t.Run("single element", func(t *testing.T) {
+		t.Parallel()
+		require.Equal(t, 42, iter.Reduce([]int{42}, add))
+	})
+
+	t.Run("huge inputs", func(t *testing.T) {
+		t.Parallel()
+		ints := make([]int, 10000)
+		for i := range ints {
+			ints[i] = i
+		}
+		require.Equal(t, 9999*10000/2, iter.Reduce(ints, add))
+	})
+
+	t.Run("non-commutative", func(t *testing.T) {
+		t.Parallel()
+		input := make([]string, 1000)
+		for i := range input {
+			input[i] = fmt.Sprint(i % 10)
+		}
+		iterator := iter.Iterator[string]{MaxGoroutines: 7}
+		res := iterator.Reduce(input, func(a, b string) string { return a + b })
+		require.Equal(t, strings.Join(input, ""), res)
+	})
+
+	t.Run("panic is propagated", func(t *testing.T) {
+		t.Parallel()
+		f := func() {
+			iter.Reduce([]int{1, 2}, func(int, int) int {
+				panic("super bad thing happened")
+			})
+		}
+		require.Panics(t, f)
+	})
+}
+
+func TestMapReduce(t *testing.T) {
+	t.Parallel()
+
+	t.Run("empty", func(t *testing.T) {
+		t.Parallel()
+		res := iter.MapReduce([]int{}, func(*int) string {
+			panic("this should never be called")
+		}, func(string, string) string {
+			panic("this should never be called")
+		})
+		require.Equal(t, "", res)
+	})
+
+	t.Run("ordered", func(t *testing.T) {
+		t.Parallel()
+		input := make([]int, 1000)
+		var expected strings.Builder
+		for i := range input {
+			input[i] = i
+			expected.WriteString(fmt.Sprint(i))
+		}
+		mapper := iter.Mapper[int, string]{MaxGoroutines: 3}
+		res := mapper.MapReduce(input, func(i *int) string {
+			return fmt.Sprint(*i)
+		}, func(a, b string) string {
+			return a + b
+		})
+		require.Equal(t, expected.String(), res)
+	})
+}
+
+func TestFold(t *testing.T) {
+	t.Parallel()
+
+	t.Run("empty", func(t *testing.T) {
+		t.Parallel()
+		res := iter.Fold([]int{}, func() int { return 1 }, func(int, *int) int {
+			panic("this should never be called")
+		}, func(a, b int) int { return a * b })
+		require.Equal(t, 1, res)
+	})
+
+	t.Run("count", func(t *testing.T) {
+		t.Parallel()
+		input := make([]int, 10000)
+		for i := range input {
+			input[i] = i % 3
+		}
+		res := iter.Fold(input,
+			func() [3]int { return [3]int{} },
+			func(acc [3]int, i *int) [3]int {
+				acc[*i]++
+				return acc
+			},
+			func(a, b [3]int) [3]int {
+				for i := range a {
+					a[i] += b[i]
+				}
+				return a
+			},
+		)
+		require.Equal(t, [3]int{3334, 3333, 3333}, res)
+	})
+}
@@ -0,0 +1,227 @@
+package iter
+
+import (
+	"github.com/sourcegraph/conc"
+	"github.com/sourcegraph/conc/panics"
+	"github.com/sourcegraph/conc/pool"
+)
+
+// The functions in this file accept sequences as plain functions rather than
+// as iter.Seq and iter.Seq2 from the standard library so that they can be
+// used with older versions of Go. Values of type iter.Seq[T] and
+// iter.Seq2[K, V] can be passed to them directly.
+
+// ForEachSeq executes f in parallel over each value produced by seq, which
+// can be an iter.Seq[T]. Values are pulled from seq lazily, only as fast as
+// they can be processed.
+//
+// ForEachSeq always uses at most runtime.GOMAXPROCS goroutines. For a
+// configurable goroutine limit, use a custom Iterator.
+func ForEachSeq[T any](seq func(yield func(T) bool), f func(T)) {
+	Iterator[T]{}.ForEachSeq(seq, f)
+}
+
+// ForEachSeq executes f in parallel over each value produced by seq, which
+// can be an iter.Seq[T], using up to the Iterator's configured maximum number
+// of goroutines. Values are pulled from seq lazily, only as fast as they can
+// be processed.
+func (iter Iterator[T]) ForEachSeq(seq func(yield func(T) bool), f func(T)) {
+	p := newSeqPool(iter.MaxGoroutines)
+	defer p.Wait()
+
+	seq(func(t T) bool {
+		p.Go(func() { f(t) })
+		return true
+	})
+}
+
+// ForEachSeq2 executes f in parallel over each pair of values produced by
+// seq, which can be an iter.Seq2[K, V]. Values are pulled from seq lazily,
+// only as fast as they can be processed.
+//
+// ForEachSeq2 always uses at most runtime.GOMAXPROCS goroutines.
+func ForEachSeq2[K, V any](seq func(yield func(K, V) bool), f func(K, V)) {
+	p := newSeqPool(0)
+	defer p.Wait()
+
+	seq(func(k K, v V) bool {
+		p.Go(func() { f(k, v) })
+		return true
+	})
+}
+
+// FromChan returns a sequence that yields the values received from ch until
+// ch is closed. It can be used to pass a channel to ForEachSeq and MapSeq.
+func FromChan[T any](ch <-chan T) func(yield func(T) bool) {
+	return func(yield func(T) bool) {
+		for t := range ch {
+			if !yield(t) {
+				return
+			}
+		}
+	}
+}
+
+// MapSeq returns a sequence, usable as an iter.Seq[R], that applies f in
+// parallel to each value produced by seq and yields the results in the order
+// they complete. Nothing is computed until the returned sequence is iterated.
+//
+// Stopping the iteration early stops pulling values from seq and waits for
+// the calls to f that are in progress. A panic in f ends the iteration, and is
+// propagated once the calls to f that are in progress have returned.
+//
+// MapSeq always uses at most runtime.GOMAXPROCS goroutines. For a
+// configurable goroutine limit, use a custom Mapper.
+func MapSeq[T, R any](seq func(yield func(T) bool), f func(T) R) func(yield func(R) bool) {
+	return Mapper[T, R]{}.MapSeq(seq, f)
+}
+
+// MapSeq returns a sequence, usable as an iter.Seq[R], that applies f in
+// parallel to each value produced by seq and yields the results in the order
+// they complete. Nothing is computed until the returned sequence is iterated.
+//
+// Stopping the iteration early stops pulling values from seq and waits for
+// the calls to f that are in progress. A panic in f ends the iteration, and is
+// propagated once the calls to f that are in progress have returned.
+//
+// MapSeq uses up to the configured Mapper's maximum number of goroutines.
+func (m Mapper[T, R]) MapSeq(seq func(yield func(T) bool), f func(T) R) func(yield func(R) bool) {
+	return m.mapSeq(seq, f, false)
+}
+
+// MapSeqOrdered is the same as MapSeq, except that the results are yielded in
+// the same order as the values of seq.
+//
+// MapSeqOrdered always uses at most runtime.GOMAXPROCS goroutines. For a
+// configurable goroutine limit, use a custom Mapper.
+func MapSeqOrdered[T, R any](seq func(yield func(T) bool), f func(T) R) func(yield func(R) bool) {
+	return Mapper[T, R]{}.MapSeqOrdered(seq, f)
+}
+
+// MapSeqOrdered is the same as MapSeq, except that the results are yielded in
+// the same order as the values of seq. Results that complete ahead of an
+// earlier one are buffered until the earlier one completes. At most twice
+// the maximum number of goroutines values are in flight or buffered at once,
+// so no more values are pulled from seq while an earlier result is late.
+//
+// MapSeqOrdered uses up to the configured Mapper's maximum number of
+// goroutines.
+func (m Mapper[T, R]) MapSeqOrdered(seq func(yield func(T) bool), f func(T) R) func(yield func(R) bool) {
+	return m.mapSeq(seq, f, true)
+}
+
+func (m Mapper[T, R]) mapSeq(seq func(yield func(T) bool), f func(T) R, ordered bool) func(yield func(R) bool) {
+	type result struct {
+		idx       int
+		res       R
+		recovered *panics.Recovered
+	}
+
+	maxGoroutines := m.MaxGoroutines
+	if maxGoroutines == 0 {
+		maxGoroutines = defaultMaxGoroutines()
+	}
+
+	return func(yield func(R) bool) {
+		results := make(chan result)
+		done := make(chan struct{})
+
+		// window bounds the number of values that are in flight or whose
+		// results are buffered, when ordered. A slot is taken before a value
+		// is submitted, and released once its result has been yielded, so
+		// the producer blocks while the earliest result is late.
+		var window chan struct{}
+		if ordered {
+			window = make(chan struct{}, 2*maxGoroutines)
+		}
+
+		// The producer pulls values from seq and runs f on them in a pool.
+		var producer conc.WaitGroup
+		producer.Go(func() {
+			defer close(results)
+
+			p := newSeqPool(maxGoroutines)
+			defer p.Wait()
+
+			idx := 0
+			seq(func(t T) bool {
+				if ordered {
+					select {
+					case window <- struct{}{}:
+					case <-done:
+						return false
+					}
+				}
+				select {
+				case <-done:
+					return false
+				default:
+				}
+
+				i := idx
+				idx++
+				p.Go(func() {
+					// Recover here rather than in the pool so that the
+					// consumer learns about the panic right away. Otherwise,
+					// when ordered, the panicking value would never release
+					// its slot of the window, and the producer would block.
+					res, recovered := panics.TryValue(func() R { return f(t) })
+					select {
+					case results <- result{idx: i, res: res, recovered: recovered}:
+					case <-done:
+					}
+				})
+				return true
+			})
+		})
+
+		// Propagate a panic from f once the producer has been waited for.
+		var recovered *panics.Recovered
+		defer func() {
+			if recovered != nil {
+				panic(recovered)
+			}
+		}()
+
+		// Tell the producer to stop before waiting for it, whether the
+		// iteration ended normally or not.
+		defer producer.Wait()
+		defer close(done)
+
+		pending := make(map[int]R)
+		next := 0
+		for res := range results {
+			if res.recovered != nil {
+				recovered = res.recovered
+				return
+			}
+			if !ordered {
+				if !yield(res.res) {
+					return
+				}
+				continue
+			}
+
+			pending[res.idx] = res.res
+			for {
+				r, ok := pending[next]
+				if !ok {
+					break
+				}
+				delete(pending, next)
+				next++
+				<-window
+				if !yield(r) {
+					return
+				}
+			}
+		}
+	}
+}
+
+func newSeqPool(maxGoroutines int) *pool.Pool {
+	if maxGoroutines == 0 {
+		maxGoroutines = defaultMaxGoroutines()
+	}
+	return pool.New().WithMaxGoroutines(maxGoroutines)
+}
@@ -0,0 +1,243 @@
+package iter_test
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/sourcegraph/conc/iter"
+
+	"github.com/stretchr/testify/require"
+)
+
+// count returns a sequence that yields the integers from 0 to n-1.
+func count(n int) func(yield func(int) bool) {
+	return func(yield func(int) bool) {
+		for i := 0; i < n; i++ {
+			if !yield(i) {
+				return
+			}
+		}
+	}
+}
+
+func ExampleMapSeqOrdered() {
+	squares := iter.MapSeqOrdered(count(5), func(i int) int { return i * i })
+	squares(func(i int) bool {
+		fmt.Println(i)
+		return true
+	})
+	// Output:
+	// 0
+	// 1
+	// 4
+	// 9
+	// 16
+}
+
+func TestForEachSeq(t *testing.T) {
+	t.Parallel()
+
+	t.Run("empty", func(t *testing.T) {
+		t.Parallel()
+		f := func() {
+			iter.ForEachSeq(count(0), func(int) {
+				panic("this should never be called")
+			})
+		}
+		require.NotPanics(t, f)
+	})
+
+	t.Run("all values are processed", func(t *testing.T) {
+		t.Parallel()
+		var sum atomic.Int64
+		iter.ForEachSeq(count(1000), func(i int) {
+			sum.Add(int64(i))
+		})
+		require.Equal(t, int64(999*1000/2), sum.Load())
+	})
+
+	t.Run("limit", func(t *testing.T) {
+		t.Parallel()
+		var current, errCount atomic.Int64
+		iterator := iter.Iterator[int]{MaxGoroutines: 3}
+		iterator.ForEachSeq(count(100), func(int) {
+			if current.Add(1) > 3 {
+				errCount.Add(1)
+			}
+			current.Add(-1)
+		})
+		require.Equal(t, int64(0), errCount.Load())
+	})
+
+	t.Run("panic is propagated", func(t *testing.T) {
+		t.Parallel()
+		f := func() {
+			iter.ForEachSeq(count(10), func(int) {
+				panic("super bad thing happened")
+			})
+		}
+		require.Panics(t, f)
+	})
+
+	t.Run("channel", func(t *testing.T) {
+		t.Parallel()
+		ch := make(chan int)
+		go func() {
+			defer close(ch)
+			for i := 0; i < 100; i++ {
+				ch <- i
+			}
+		}()
+		var sum atomic.Int64
+		iter.ForEachSeq(iter.FromChan(ch), func(i int) {
+			sum.Add(int64(i))
+		})
+		require.Equal(t, int64(99*100/2), sum.Load())
+	})
+}
+
+func TestForEachSeq2(t *testing.T) {
+	t.Parallel()
+
+	seq := func(yield func(string, int) bool) {
+		for i := 0; i < 10; i++ {
+			if !yield(fmt.Sprint(i), i) {
+				return
+			}
+		}
+	}
+
+	var mu sync.Mutex
+	got := make(map[string]int)
+	iter.ForEachSeq2(seq, func(k string, v int) {
+		mu.Lock()
+		got[k] = v
+		mu.Unlock()
+	})
+	require.Len(t, got, 10)
+	require.Equal(t, 7, got["7"])
+}
+
+func TestMapSeq(t *testing.T) {
+	t.Parallel()
+
+	collect := func(seq func(yield func(int) bool)) []int {
+		var res []int
+		seq(func(i int) bool {
+			res = append(res, i)
+			return true
+		})
+		return res
+	}
+
+	expected := make([]int, 1000)
+	for i := range expected {
+		expected[i] = i * 2
+	}
+	double := func(i int) int { return i * 2 }
+
+	t.Run("unordered", func(t *testing.T) {
+		t.Parallel()
+		res := collect(iter.MapSeq(count(1000), double))
+		sort.Ints(res)
+		require.Equal(t, expected, res)
+	})
+
+	t.Run("ordered", func(t *testing.T) {
+		t.Parallel()
+		mapper := iter.Mapper[int, int]{MaxGoroutines: 7}
+		res := collect(mapper.MapSeqOrdered(count(1000), double))
+		require.Equal(t, expected, res)
+	})
+
+	t.Run("lazy", func(t *testing.T) {
+		t.Parallel()
+		_ = iter.MapSeq(count(10), func(int) int {
+			panic("this should never be called")
+		})
+	})
+
+	t.Run("stopping early", func(t *testing.T) {
+		t.Parallel()
+		// source never ends, so this only returns if stopping works.
+		source := func(yield func(int) bool) {
+			for i := 0; ; i++ {
+				if !yield(i) {
+					return
+				}
+			}
+		}
+		var res []int
+		iter.MapSeqOrdered(source, double)(func(i int) bool {
+			res = append(res, i)
+			return len(res) < 5
+		})
+		require.Equal(t, []int{0, 2, 4, 6, 8}, res)
+	})
+
+	t.Run("ordered buffering is bounded", func(t *testing.T) {
+		t.Parallel()
+		var pulled atomic.Int64
+		source := func(yield func(int) bool) {
+			for i := 0; ; i++ {
+				pulled.Add(1)
+				if !yield(i) {
+					return
+				}
+			}
+		}
+		release := make(chan struct{})
+		mapper := iter.Mapper[int, int]{MaxGoroutines: 4}
+		seq := mapper.MapSeqOrdered(source, func(i int) int {
+			if i == 0 {
+				<-release
+			}
+			return i
+		})
+
+		go func() {
+			// Give the producer time to run ahead of the late first result.
+			time.Sleep(50 * time.Millisecond)
+			close(release)
+		}()
+		var res []int
+		var pulledBeforeFirst int64
+		seq(func(i int) bool {
+			if len(res) == 0 {
+				pulledBeforeFirst = pulled.Load()
+			}
+			res = append(res, i)
+			return len(res) < 3
+		})
+		require.Equal(t, []int{0, 1, 2}, res)
+		// Eight values in the window, plus one waiting for a slot.
+		require.LessOrEqual(t, pulledBeforeFirst, int64(9))
+	})
+
+	t.Run("panic is propagated", func(t *testing.T) {
+		t.Parallel()
+		f := func() {
+			collect(iter.MapSeq(count(10), func(int) int {
+				panic("super bad thing happened")
+			}))
+		}
+		require.Panics(t, f)
+	})
+	t.Run("ordered panic is propagated", func(t *testing.T) {
+		t.Parallel()
+		mapper := iter.Mapper[int, int]{MaxGoroutines: 2}
+		f := func() {
+			collect(mapper.MapSeqOrdered(count(100), func(i int) int {
+				if i == 0 {
+					panic("super bad thing happened")
+				}
+				return i
+			}))
+		}
+		require.Panics(t, f)
+	})
+}
@@ -0,0 +1,220 @@
+package iter
+
+import (
+	"sort"
+
+	"github.com/sourcegraph/conc"
+)
+
+// minParallelSortSize is the input length below which sorting in parallel
+// isn't worth the overhead of spawning goroutines and merging.
+const minParallelSortSize = 4096
+
+// ordered is a constraint that permits any ordered type: any type that
+// supports the operators < <= >= >.
+type ordered interface {
+	~int | ~int8 | ~int16 | ~int32 | ~int64 |
+		~uint | ~uint8 | ~uint16 | ~uint32 | ~uint64 | ~uintptr |
+		~float32 | ~float64 |
+		~string
+}
+
+// Sort sorts input in ascending order, in parallel. As with slices.Sort, NaN
+// values are ordered before other values.
+//
+// Sort always uses at most runtime.GOMAXPROCS goroutines. For a configurable
+// goroutine limit, use SortWith with a custom Iterator.
+func Sort[T ordered](input []T) {
+	SortWith(Iterator[T]{}, input)
+}
+
+// SortWith sorts input in ascending order, in parallel, using up to the
+// Iterator's configured maximum number of goroutines. As with slices.Sort,
+// NaN values are ordered before other values.
+func SortWith[T ordered](iter Iterator[T], input []T) {
+	iter.SortFunc(input, compareOrdered[T])
+}
+
+// SortFunc sorts input in ascending order as determined by cmp, in parallel.
+// cmp must return a negative number when a < b, a positive number when
+// a > b and zero when a == b, like for slices.SortFunc. SortFunc is not
+// guaranteed to be stable.
+//
+// SortFunc always uses at most runtime.GOMAXPROCS goroutines. For a
+// configurable goroutine limit, use a custom Iterator.
+func SortFunc[T any](input []T, cmp func(a, b T) int) {
+	Iterator[T]{}.SortFunc(input, cmp)
+}
+
+// SortFunc sorts input in ascending order as determined by cmp, in parallel,
+// using up to the Iterator's configured maximum number of goroutines. cmp
+// must return a negative number when a < b, a positive number when a > b and
+// zero when a == b, like for slices.SortFunc. SortFunc is not guaranteed to
+// be stable.
+func (iter Iterator[T]) SortFunc(input []T, cmp func(a, b T) int) {
+	iter.sortFunc(input, cmp, false)
+}
+
+// SortStableFunc is the same as SortFunc, except that it keeps the original
+// order of equal elements, like slices.SortStableFunc.
+//
+// SortStableFunc always uses at most runtime.GOMAXPROCS goroutines. For a
+// configurable goroutine limit, use a custom Iterator.
+func SortStableFunc[T any](input []T, cmp func(a, b T) int) {
+	Iterator[T]{}.SortStableFunc(input, cmp)
+}
+
+// SortStableFunc is the same as SortFunc, except that it keeps the original
+// order of equal elements, like slices.SortStableFunc.
+func (iter Iterator[T]) SortStableFunc(input []T, cmp func(a, b T) int) {
+	iter.sortFunc(input, cmp, true)
+}
+
+// sortFunc is a parallel merge sort. The input is split into one partition
+// per goroutine, each partition is sorted sequentially, then pairs of sorted
+// runs are merged in parallel until a single run is left. The merge is
+// stable, so the whole sort is stable if the partitions are sorted stably.
+func (iter Iterator[T]) sortFunc(input []T, cmp func(a, b T) int, stable bool) {
+	sortRun := func(run []T) {
+		less := func(i, j int) bool { return cmp(run[i], run[j]) < 0 }
+		if stable {
+			sort.SliceStable(run, less)
+		} else {
+			sort.Slice(run, less)
+		}
+	}
+
+	numParts := iter.numPartitions(len(input))
+	if numParts < 2 || len(input) < minParallelSortSize {
+		sortRun(input)
+		return
+	}
+
+	forEachPartition(len(input), numParts, func(_, start, end int) {
+		sortRun(input[start:end])
+	})
+
+	// bounds holds the start of each sorted run, followed by the input's
+	// length. It matches the partitions of forEachPartition.
+	bounds := make([]int, 0, numParts+1)
+	for part := 0; part <= numParts; part++ {
+		bounds = append(bounds, part*len(input)/numParts)
+	}
+
+	src, dst := input, make([]T, len(input))
+	for len(bounds) > 2 {
+		next := []int{0}
+		var wg conc.WaitGroup
+		for i := 0; i+2 < len(bounds); i += 2 {
+			lo, mid, hi := bounds[i], bounds[i+1], bounds[i+2]
+			wg.Go(func() {
+				mergeRuns(dst[lo:hi], src[lo:mid], src[mid:hi], cmp)
+			})
+			next = append(next, hi)
+		}
+		if len(bounds)%2 == 0 {
+			// There is an odd number of runs, so the last one has nothing to
+			// be merged with in this round.
+			lo, hi := bounds[len(bounds)-2], bounds[len(bounds)-1]
+			copy(dst[lo:hi], src[lo:hi])
+			next = append(next, hi)
+		}
+		wg.Wait()
+
+		bounds = next
+		src, dst = dst, src
+	}
+
+	if &src[0] != &input[0] {
+		copy(input, src)
+	}
+}
+
+// mergeRuns stably merges the sorted runs left and right into dst.
+func mergeRuns[T any](dst, left, right []T, cmp func(a, b T) int) {
+	i, j, k := 0, 0, 0
+	for i < len(left) && j < len(right) {
+		// Only take from right if it is strictly smaller to keep the merge
+		// stable.
+		if cmp(right[j], left[i]) < 0 {
+			dst[k] = right[j]
+			j++
+		} else {
+			dst[k] = left[i]
+			i++
+		}
+		k++
+	}
+	k += copy(dst[k:], left[i:])
+	copy(dst[k:], right[j:])
+}
+
+// compareOrdered is the same as cmp.Compare, which isn't available in all
+// supported versions of Go.
+func compareOrdered[T ordered](a, b T) int {
+	aNaN := isNaN(a)
+	bNaN := isNaN(b)
+	if aNaN {
+		if bNaN {
+			return 0
+		}
+		return -1
+	}
+	if bNaN {
+		return 1
+	}
+	if a < b {
+		return -1
+	}
+	if a > b {
+		return 1
+	}
+	return 0
+}
+
+// isNaN reports whether x is a NaN without requiring a math.IsNaN call.
+func isNaN[T ordered](x T) bool {
+	return x != x
+}
+
+// GroupBy partitions the elements of input by the key returned by f, in
+// parallel. The elements of each group keep their order from input.
+//
+// Each goroutine groups a contiguous partition of the input into its own map,
+// and the maps are merged once all goroutines are done, so no locking is
+// needed.
+//
+// GroupBy always uses at most runtime.GOMAXPROCS goroutines. For a
+// configurable goroutine limit, use GroupByWith with a custom Iterator.
+func GroupBy[T any, K comparable](input []T, f func(*T) K) map[K][]T {
+	return GroupByWith(Iterator[T]{}, input, f)
+}
+
+// GroupByWith partitions the elements of input by the key returned by f, in
+// parallel, using up to the Iterator's configured maximum number of
+// goroutines. The elements of each group keep their order from input.
+func GroupByWith[T any, K comparable](iter Iterator[T], input []T, f func(*T) K) map[K][]T {
+	numParts := iter.numPartitions(len(input))
+	groups := make([]map[K][]T, numParts)
+	forEachPartition(len(input), numParts, func(part, start, end int) {
+		g := make(map[K][]T)
+		for i := start; i < end; i++ {
+			k := f(&input[i])
+			g[k] = append(g[k], input[i])
+		}
+		groups[part] = g
+	})
+
+	if len(groups) == 0 {
+		return make(map[K][]T)
+	}
+
+	// Merge the groups in partition order to preserve the input order.
+	res := groups[0]
+	for _, g := range groups[1:] {
+		for k, vs := range g {
+			res[k] = append(res[k], vs...)
+		}
+	}
+	return res
+}
@@ -0,0 +1,164 @@
+package iter_test
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+	"sort"
+	"testing"
+
+	"github.com/sourcegraph/conc/iter"
+
+	"github.com/stretchr/testify/require"
+)
+
+func ExampleSort() {
+	input := []int{5, 2, 4, 1, 3}
+	iter.Sort(input)
+	fmt.Println(input)
+	// Output:
+	// [1 2 3 4 5]
+}
+
+func ExampleGroupBy() {
+	input := []int{1, 2, 3, 4, 5, 6}
+	groups := iter.GroupBy(input, func(v *int) bool { return *v%2 == 0 })
+	fmt.Println(groups[true], groups[false])
+	// Output:
+	// [2 4 6] [1 3 5]
+}
+
+type keyed struct {
+	key int
+	seq int
+}
+
+func randomKeyed(n int) []keyed {
+	r := rand.New(rand.NewSource(int64(n)))
+	input := make([]keyed, n)
+	for i := range input {
+		input[i] = keyed{key: r.Intn(100), seq: i}
+	}
+	return input
+}
+
+func compareKeyed(a, b keyed) int {
+	return a.key - b.key
+}
+
+func TestSort(t *testing.T) {
+	t.Parallel()
+
+	t.Run("empty", func(t *testing.T) {
+		t.Parallel()
+		var input []int
+		iter.Sort(input)
+		require.Empty(t, input)
+	})
+
+	t.Run("ordered types", func(t *testing.T) {
+		t.Parallel()
+		r := rand.New(rand.NewSource(1))
+		input := make([]float64, 10000)
+		for i := range input {
+			input[i] = r.Float64()
+		}
+		input[42] = math.NaN()
+		expected := append([]float64(nil), input...)
+		sort.Float64s(expected)
+
+		iter.Sort(input)
+		require.True(t, math.IsNaN(input[0]))
+		require.Equal(t, expected[1:], input[1:])
+	})
+
+	t.Run("ordered types with iterator", func(t *testing.T) {
+		t.Parallel()
+		r := rand.New(rand.NewSource(1))
+		input := make([]int, 10000)
+		for i := range input {
+			input[i] = r.Int()
+		}
+		expected := append([]int(nil), input...)
+		sort.Ints(expected)
+
+		iter.SortWith(iter.Iterator[int]{MaxGoroutines: 3}, input)
+		require.Equal(t, expected, input)
+	})
+
+	for _, size := range []int{10, 10000, 100003} {
+		size := size
+
+		t.Run(fmt.Sprintf("stable %d", size), func(t *testing.T) {
+			t.Parallel()
+			input := randomKeyed(size)
+			expected := append([]keyed(nil), input...)
+			sort.SliceStable(expected, func(i, j int) bool { return expected[i].key < expected[j].key })
+
+			iterator := iter.Iterator[keyed]{MaxGoroutines: 5}
+			iterator.SortStableFunc(input, compareKeyed)
+			require.Equal(t, expected, input)
+		})
+
+		t.Run(fmt.Sprintf("unstable %d", size), func(t *testing.T) {
+			t.Parallel()
+			input := randomKeyed(size)
+			iter.SortFunc(input, compareKeyed)
+			require.True(t, sort.SliceIsSorted(input, func(i, j int) bool { return input[i].key < input[j].key }))
+
+			seen := make([]bool, size)
+			for _, v := range input {
+				seen[v.seq] = true
+			}
+			require.NotContains(t, seen, false)
+		})
+	}
+}
+
+func TestGroupBy(t *testing.T) {
+	t.Parallel()
+
+	t.Run("empty", func(t *testing.T) {
+		t.Parallel()
+		res := iter.GroupBy([]int{}, func(*int) int {
+			panic("this should never be called")
+		})
+		require.NotNil(t, res)
+		require.Empty(t, res)
+	})
+
+	t.Run("order is preserved", func(t *testing.T) {
+		t.Parallel()
+		input := randomKeyed(10000)
+		expected := make(map[int][]keyed)
+		for _, v := range input {
+			expected[v.key] = append(expected[v.key], v)
+		}
+		res := iter.GroupBy(input, func(v *keyed) int { return v.key })
+		require.Equal(t, expected, res)
+	})
+
+	t.Run("order is preserved with iterator", func(t *testing.T) {
+		t.Parallel()
+		input := randomKeyed(10000)
+		expected := make(map[int][]keyed)
+		for _, v := range input {
+			expected[v.key] = append(expected[v.key], v)
+		}
+		for _, maxGoroutines := range []int{1, 3, 64} {
+			iterator := iter.Iterator[keyed]{MaxGoroutines: maxGoroutines}
+			res := iter.GroupByWith(iterator, input, func(v *keyed) int { return v.key })
+			require.Equal(t, expected, res)
+		}
+	})
+
+	t.Run("panic is propagated", func(t *testing.T) {
+		t.Parallel()
+		f := func() {
+			iter.GroupBy([]int{1}, func(*int) int {
+				panic("super bad thing happened")
+			})
+		}
+		require.Panics(t, f)
+	})
+}
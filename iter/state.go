@@ -0,0 +1,53 @@
+package iter
+
+import (
+	"sync"
+)
+
+// ForEachWithState executes f in parallel over each element in input, like
+// ForEach. Each goroutine lazily creates its own state with newState the
+// first time it claims an element, and passes it to f for every element it
+// processes. Since a state is only ever used by one goroutine at a time, it
+// can be used for scratch space, such as buffers or parsers, without
+// synchronization.
+//
+// ForEachWithState returns the states that were created, which can be used
+// to release resources or to merge per-goroutine results.
+//
+// ForEachWithState always uses at most runtime.GOMAXPROCS goroutines, and
+// hence creates at most that many states. For a configurable goroutine
+// limit, use ForEachWithStateWith with a custom Iterator.
+func ForEachWithState[T, S any](input []T, newState func() S, f func(S, *T)) []S {
+	return ForEachWithStateWith(Iterator[T]{}, input, newState, f)
+}
+
+// ForEachWithStateWith is the same as ForEachWithState, except that it uses
+// up to the Iterator's configured maximum number of goroutines, and hence
+// creates at most that many states. This makes it possible to bound the
+// number of expensive states, such as connections.
+func ForEachWithStateWith[T, S any](iter Iterator[T], input []T, newState func() S, f func(S, *T)) []S {
+	var (
+		mu     sync.Mutex
+		states []S
+	)
+	iter.forEachChunkWorker(len(input), func() func(start, end int) bool {
+		var (
+			state   S
+			created bool
+		)
+		return func(start, end int) bool {
+			if !created {
+				state = newState()
+				created = true
+				mu.Lock()
+				states = append(states, state)
+				mu.Unlock()
+			}
+			for i := start; i < end; i++ {
+				f(state, &input[i])
+			}
+			return true
+		}
+	})
+	return states
+}
@@ -0,0 +1,116 @@
+package iter_test
+
+import (
+	"bytes"
+	"fmt"
+	"strconv"
+	"sync/atomic"
+	"testing"
+
+	"github.com/sourcegraph/conc/iter"
+
+	"github.com/stretchr/testify/require"
+)
+
+func ExampleForEachWithState() {
+	input := []int{1, 2, 3, 4, 5}
+	type counter struct{ sum int }
+
+	states := iter.ForEachWithState(input, func() *counter {
+		return &counter{}
+	}, func(c *counter, v *int) {
+		c.sum += *v
+	})
+
+	total := 0
+	for _, c := range states {
+		total += c.sum
+	}
+	fmt.Println(total)
+	// Output:
+	// 15
+}
+
+func TestForEachWithState(t *testing.T) {
+	t.Parallel()
+
+	t.Run("empty", func(t *testing.T) {
+		t.Parallel()
+		states := iter.ForEachWithState([]int{}, func() int {
+			panic("this should never be called")
+		}, func(int, *int) {
+			panic("this should never be called")
+		})
+		require.Empty(t, states)
+	})
+
+	t.Run("states are not shared", func(t *testing.T) {
+		t.Parallel()
+		ints := make([]int, 10000)
+		var inUse atomic.Int64
+		var shared atomic.Int64
+		type state struct {
+			buf  bytes.Buffer
+			busy atomic.Bool
+		}
+		states := iter.ForEachWithState(ints, func() *state {
+			return &state{}
+		}, func(s *state, v *int) {
+			if !s.busy.CompareAndSwap(false, true) {
+				shared.Add(1)
+			}
+			inUse.Add(1)
+			s.buf.Reset()
+			s.buf.WriteString(strconv.Itoa(*v))
+			s.busy.Store(false)
+		})
+		require.Equal(t, int64(0), shared.Load())
+		require.Equal(t, int64(10000), inUse.Load())
+		require.NotEmpty(t, states)
+		require.LessOrEqual(t, len(states), iter.DefaultMaxGoroutines())
+	})
+
+	t.Run("panic is propagated", func(t *testing.T) {
+		t.Parallel()
+		f := func() {
+			iter.ForEachWithState([]int{1}, func() int { return 0 }, func(int, *int) {
+				panic("super bad thing happened")
+			})
+		}
+		require.Panics(t, f)
+	})
+}
+
+func TestForEachWithStateWith(t *testing.T) {
+	t.Parallel()
+
+	t.Run("states are limited", func(t *testing.T) {
+		t.Parallel()
+		ints := make([]int, 1000)
+		var created atomic.Int64
+		iterator := iter.Iterator[int]{MaxGoroutines: 2}
+		states := iter.ForEachWithStateWith(iterator, ints, func() int {
+			created.Add(1)
+			return 0
+		}, func(_ int, v *int) {
+			*v = 1
+		})
+		require.LessOrEqual(t, len(states), 2)
+		require.Equal(t, int64(len(states)), created.Load())
+		for _, v := range ints {
+			require.Equal(t, 1, v)
+		}
+	})
+
+	t.Run("single goroutine", func(t *testing.T) {
+		t.Parallel()
+		iterator := iter.Iterator[int]{MaxGoroutines: 1}
+		states := iter.ForEachWithStateWith(iterator, []int{1, 2, 3}, func() *[]int {
+			return &[]int{}
+		}, func(s *[]int, v *int) {
+			*s = append(*s, *v)
+		})
+		require.Len(t, states, 1)
+		require.Equal(t, []int{1, 2, 3}, *states[0])
+	})
+}
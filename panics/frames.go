@@ -0,0 +1,114 @@
+package panics
+
+import (
+	"encoding/json"
+	"fmt"
+	"runtime"
+	"strings"
+)
+
+// concModule is the module path of this library. Frames from packages in it
+// are considered internal when trimming a stack to user code.
+const concModule = "github.com/sourcegraph/conc"
+
+// Frame is a single decoded frame of the stack of a recovered panic.
+type Frame struct {
+	// The fully qualified name of the function, including its package path.
+	Function string `json:"function"`
+	// The file and line of the call site within the function.
+	File string `json:"file"`
+	Line int    `json:"line"`
+}
+
+// String renders the frame in the same format as a stacktrace.
+func (f Frame) String() string {
+	return fmt.Sprintf("%s\n\t%s:%d", f.Function, f.File, f.Line)
+}
+
+// Frames decodes Callers into structured stack frames, starting with the
+// innermost one.
+func (p *Recovered) Frames() []Frame {
+	return framesOf(p.Callers)
+}
+
+// SpawnFrames decodes SpawnCallers into structured stack frames, starting
+// with the innermost one. It returns nil if the spawn stack was not captured.
+func (p *Recovered) SpawnFrames() []Frame {
+	return framesOf(p.SpawnCallers)
+}
+
+// UserFrames is like Frames, but with the leading frames that belong to the
+// Go runtime or to this library removed, so that the first frame is the code
+// that panicked.
+func (p *Recovered) UserFrames() []Frame {
+	frames := p.Frames()
+	for i, f := range frames {
+		if !isInternalFrame(f) {
+			return frames[i:]
+		}
+	}
+	return nil
+}
+
+// MarshalJSON renders the panic as a JSON object with the panic value, its
+// type, the decoded frames, the formatted stacktrace, and the spawn frames
+// if they were captured.
+func (p *Recovered) MarshalJSON() ([]byte, error) {
+	return json.Marshal(struct {
+		Value       string  `json:"value"`
+		Type        string  `json:"type"`
+		Frames      []Frame `json:"frames"`
+		Stack       string  `json:"stack"`
+		SpawnFrames []Frame `json:"spawnFrames,omitempty"`
+	}{
+		Value:       fmt.Sprint(p.Value),
+		Type:        fmt.Sprintf("%T", p.Value),
+		Frames:      p.Frames(),
+		Stack:       string(p.Stack),
+		SpawnFrames: p.SpawnFrames(),
+	})
+}
+
+func framesOf(callers []uintptr) []Frame {
+	if len(callers) == 0 {
+		return nil
+	}
+	res := make([]Frame, 0, len(callers))
+	frames := runtime.CallersFrames(callers)
+	for {
+		frame, more := frames.Next()
+		res = append(res, Frame{
+			Function: frame.Function,
+			File:     frame.File,
+			Line:     frame.Line,
+		})
+		if !more {
+			return res
+		}
+	}
+}
+
+// isInternalFrame returns whether f belongs to the Go runtime or to a
+// package of this library. Test packages of this library are not considered
+// internal.
+func isInternalFrame(f Frame) bool {
+	pkg := packagePath(f.Function)
+	switch {
+	case pkg == "runtime" || strings.HasPrefix(pkg, "runtime/"):
+		return true
+	case strings.HasSuffix(pkg, "_test"):
+		return false
+	default:
+		return pkg == concModule || strings.HasPrefix(pkg, concModule+"/")
+	}
+}
+
+// packagePath extracts the package path from a fully qualified function
+// name such as "github.com/sourcegraph/conc/panics.(*Catcher).Try".
+func packagePath(function string) string {
+	slash := strings.LastIndexByte(function, '/')
+	if dot := strings.IndexByte(function[slash+1:], '.'); dot >= 0 {
+		return function[:slash+1+dot]
+	}
+	return function
+}
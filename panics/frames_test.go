@@ -0,0 +1,97 @@
+package panics_test
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/sourcegraph/conc/panics"
+
+	"github.com/stretchr/testify/require"
+)
+
+func ExampleRecovered_UserFrames() {
+	recovered := panics.Try(func() { panic("mayday!") })
+
+	// The first user frame is the function that panicked, rather than
+	// the runtime or conc frames that recovered it.
+	fmt.Println(recovered.UserFrames()[0].Function)
+	// Output:
+	// github.com/sourcegraph/conc/panics_test.ExampleRecovered_UserFrames.func1
+}
+
+func TestFrames(t *testing.T) {
+	t.Parallel()
+
+	t.Run("frames", func(t *testing.T) {
+		t.Parallel()
+		recovered := panics.Try(func() { panic("oh no") })
+		frames := recovered.Frames()
+		require.Len(t, frames, len(recovered.Callers))
+		require.Equal(t, "github.com/sourcegraph/conc/panics.(*Catcher).tryRecover", frames[0].Function)
+		for _, f := range frames {
+			require.NotEmpty(t, f.File)
+			require.NotZero(t, f.Line)
+		}
+	})
+
+	t.Run("user frames", func(t *testing.T) {
+		t.Parallel()
+		recovered := panics.Try(func() { panic("oh no") })
+		frames := recovered.UserFrames()
+		require.NotEmpty(t, frames)
+		require.True(t, strings.HasPrefix(frames[0].Function, "github.com/sourcegraph/conc/panics_test.TestFrames."), frames[0].Function)
+		require.True(t, strings.HasSuffix(frames[0].File, "frames_test.go"), frames[0].File)
+	})
+
+	t.Run("no callers", func(t *testing.T) {
+		t.Parallel()
+		var recovered panics.Recovered
+		require.Nil(t, recovered.Frames())
+		require.Nil(t, recovered.UserFrames())
+	})
+
+	t.Run("spawn frames", func(t *testing.T) {
+		t.Parallel()
+		var pc panics.Catcher
+		pc.Try(func() { panic("oh no") })
+		require.Nil(t, pc.Recovered().SpawnFrames())
+		require.NotContains(t, pc.Recovered().String(), "spawned by")
+
+		spawn := make([]uintptr, 64)
+		spawn = spawn[:runtime.Callers(1, spawn)]
+		pc = panics.Catcher{}
+		pc.TryFrom(spawn, func() { panic("oh no") })
+		recovered := pc.Recovered()
+		frames := recovered.SpawnFrames()
+		require.NotEmpty(t, frames)
+		require.True(t, strings.HasPrefix(frames[0].Function, "github.com/sourcegraph/conc/panics_test.TestFrames."), frames[0].Function)
+		require.Contains(t, recovered.String(), "spawned by:\n"+frames[0].Function)
+
+		b, err := json.Marshal(recovered)
+		require.NoError(t, err)
+		require.Contains(t, string(b), `"spawnFrames":`)
+	})
+
+	t.Run("json", func(t *testing.T) {
+		t.Parallel()
+		recovered := panics.Try(func() { panic(errors.New("oh no")) })
+		b, err := json.Marshal(recovered)
+		require.NoError(t, err)
+
+		var decoded struct {
+			Value  string
+			Type   string
+			Frames []panics.Frame
+			Stack  string
+		}
+		require.NoError(t, json.Unmarshal(b, &decoded))
+		require.Equal(t, "oh no", decoded.Value)
+		require.Equal(t, "*errors.errorString", decoded.Type)
+		require.Equal(t, recovered.Frames(), decoded.Frames)
+		require.Equal(t, string(recovered.Stack), decoded.Stack)
+	})
+}
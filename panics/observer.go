@@ -0,0 +1,125 @@
+package panics
+
+import (
+	"bytes"
+	"strconv"
+	"sync"
+	"sync/atomic"
+)
+
+// Goroutine describes the goroutine in which a panic was recovered.
+type Goroutine struct {
+	// The runtime's ID of the goroutine, as shown in stacktraces, or 0 if it
+	// could not be determined.
+	ID int64
+}
+
+// Observer is a function that is notified when a Catcher recovers a panic.
+//
+// Observers are called synchronously by the goroutine that panicked, before
+// Try returns, but without holding any of the Catcher's locks. Panics raised
+// by an observer are recovered and ignored.
+//
+// A panic is only observed when it is first recovered. When it is propagated
+// to another Catcher, for example because a WaitGroup is waited on in a
+// goroutine of another WaitGroup, observers are not notified again.
+type Observer func(*Recovered, Goroutine)
+
+var (
+	// observersMu serializes changes to observers. Readers load the current
+	// snapshot without locking.
+	observersMu sync.Mutex
+	observers   atomic.Pointer[[]registeredObserver]
+	nextID      uint64
+)
+
+type registeredObserver struct {
+	id uint64
+	f  Observer
+}
+
+// AddObserver registers an observer that is notified of every panic recovered
+// by any Catcher, including the ones used by conc.WaitGroup and the pools.
+// It returns a function that unregisters the observer.
+func AddObserver(f Observer) (remove func()) {
+	observersMu.Lock()
+	defer observersMu.Unlock()
+
+	nextID++
+	id := nextID
+	updateObservers(func(old []registeredObserver) []registeredObserver {
+		return append(old, registeredObserver{id: id, f: f})
+	})
+
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			observersMu.Lock()
+			defer observersMu.Unlock()
+			updateObservers(func(old []registeredObserver) []registeredObserver {
+				res := make([]registeredObserver, 0, len(old))
+				for _, o := range old {
+					if o.id != id {
+						res = append(res, o)
+					}
+				}
+				return res
+			})
+		})
+	}
+}
+
+// updateObservers replaces the snapshot of observers with a modified copy.
+// It must be called with observersMu held.
+func updateObservers(update func([]registeredObserver) []registeredObserver) {
+	var old []registeredObserver
+	if cur := observers.Load(); cur != nil {
+		old = *cur
+	}
+	// Copy so that the previous snapshot is never modified, since it may
+	// still be in use by notifyObservers.
+	next := update(append([]registeredObserver(nil), old...))
+	observers.Store(&next)
+}
+
+// notifyObservers calls the global observers, then local if it is not nil.
+func notifyObservers(rp *Recovered, local Observer) {
+	snapshot := observers.Load()
+	if (snapshot == nil || len(*snapshot) == 0) && local == nil {
+		return
+	}
+
+	g := Goroutine{ID: goroutineID(rp.Stack)}
+	if snapshot != nil {
+		for _, o := range *snapshot {
+			callObserver(o.f, rp, g)
+		}
+	}
+	if local != nil {
+		callObserver(local, rp, g)
+	}
+}
+
+func callObserver(f Observer, rp *Recovered, g Goroutine) {
+	// A misbehaving observer must not prevent the panic from being recorded
+	// or other observers from being notified.
+	defer func() { _ = recover() }()
+	f(rp, g)
+}
+
+// goroutineID parses the goroutine ID from the first line of a stacktrace,
+// which looks like "goroutine 18 [running]:".
+func goroutineID(stack []byte) int64 {
+	line, ok := bytes.CutPrefix(stack, []byte("goroutine "))
+	if !ok {
+		return 0
+	}
+	if i := bytes.IndexByte(line, ' '); i >= 0 {
+		line = line[:i]
+	}
+	id, err := strconv.ParseInt(string(line), 10, 64)
+	if err != nil {
+		return 0
+	}
+	return id
+}
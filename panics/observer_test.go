@@ -0,0 +1,114 @@
+package panics_test
+
+import (
+	"fmt"
+	"sync/atomic"
+	"testing"
+
+	"github.com/sourcegraph/conc"
+	"github.com/sourcegraph/conc/panics"
+
+	"github.com/stretchr/testify/require"
+)
+
+func ExampleAddObserver() {
+	type reportable string
+	remove := panics.AddObserver(func(r *panics.Recovered, _ panics.Goroutine) {
+		if v, ok := r.Value.(reportable); ok {
+			fmt.Println("reporting:", v)
+		}
+	})
+	defer remove()
+
+	var pc panics.Catcher
+	pc.Try(func() { panic(reportable("mayday!")) })
+	fmt.Println("recovered:", pc.Recovered().Value)
+	// Output:
+	// reporting: mayday!
+	// recovered: mayday!
+}
+
+func TestObserver(t *testing.T) {
+	t.Parallel()
+
+	t.Run("local", func(t *testing.T) {
+		t.Parallel()
+		var calls atomic.Int64
+		var got *panics.Recovered
+		var goroutine panics.Goroutine
+		pc := panics.Catcher{Observer: func(r *panics.Recovered, g panics.Goroutine) {
+			calls.Add(1)
+			got, goroutine = r, g
+		}}
+		pc.Try(func() {})
+		require.Zero(t, calls.Load())
+		pc.Try(func() { panic("oh no") })
+		require.Equal(t, int64(1), calls.Load())
+		require.Equal(t, pc.Recovered(), got)
+		require.NotZero(t, goroutine.ID)
+	})
+
+	t.Run("global", func(t *testing.T) {
+		t.Parallel()
+		type marker struct{}
+		var calls atomic.Int64
+		remove := panics.AddObserver(func(r *panics.Recovered, _ panics.Goroutine) {
+			if _, ok := r.Value.(marker); ok {
+				calls.Add(1)
+			}
+		})
+		panics.Try(func() { panic(marker{}) })
+		require.Equal(t, int64(1), calls.Load())
+
+		remove()
+		remove() // removing twice is a no-op
+		panics.Try(func() { panic(marker{}) })
+		require.Equal(t, int64(1), calls.Load())
+	})
+
+	t.Run("observer panics are ignored", func(t *testing.T) {
+		t.Parallel()
+		var calls atomic.Int64
+		pc := panics.Catcher{Observer: func(*panics.Recovered, panics.Goroutine) {
+			calls.Add(1)
+			panic("observer is broken")
+		}}
+		pc.Try(func() { panic("oh no") })
+		pc.Try(func() { panic("oh no again") })
+		require.Equal(t, int64(2), calls.Load())
+		require.Equal(t, 2, pc.Count())
+	})
+
+	t.Run("observer can use the catcher", func(t *testing.T) {
+		t.Parallel()
+		var pc panics.Catcher
+		var count int
+		pc.Observer = func(*panics.Recovered, panics.Goroutine) {
+			count = pc.Count()
+		}
+		pc.Try(func() { panic("oh no") })
+		require.Equal(t, 1, count)
+	})
+
+	t.Run("propagated panics are observed once", func(t *testing.T) {
+		t.Parallel()
+		type marker struct{}
+		var calls atomic.Int64
+		remove := panics.AddObserver(func(r *panics.Recovered, _ panics.Goroutine) {
+			if _, ok := r.Original().Value.(marker); ok {
+				calls.Add(1)
+			}
+		})
+		defer remove()
+
+		var outer conc.WaitGroup
+		outer.Go(func() {
+			var inner conc.WaitGroup
+			inner.Go(func() { panic(marker{}) })
+			inner.Wait()
+		})
+		recovered := outer.WaitAndRecover()
+		require.Len(t, recovered.Chain(), 2)
+		require.Equal(t, int64(1), calls.Load())
+	})
+}
@@ -1,35 +1,106 @@
 package panics
 
 import (
+	"errors"
 	"fmt"
 	"runtime"
 	"runtime/debug"
+	"strings"
+	"sync"
 	"sync/atomic"
 )
 
+// DefaultMaxRecovered is a reasonable value for MaxRecovered. It is enough to
+// see what went wrong when several goroutines panic, while bounding the
+// memory used when every goroutine of a large group panics.
+const DefaultMaxRecovered = 32
+
 // Catcher is used to catch panics. You can execute a function with Try,
 // which will catch any spawned panic. Try can be called any number of times,
 // from any number of goroutines. Once all calls to Try have completed, you can
 // get the value of the first panic (if any) with Recovered(), or you can just
 // propagate the panic (re-panic) with Repanic().
+//
+// If MaxRecovered is set, a Catcher also retains the panics after the first
+// one, up to MaxRecovered of them, which can be retrieved with RecoveredAll()
+// or AsError().
 type Catcher struct {
+	// MaxRecovered is the maximum number of panics retained by the Catcher.
+	// Panics caught after the limit is reached are counted, but dropped.
+	// If zero or negative, only the first panic is retained, which avoids
+	// the cost of retaining every panic and its stacktrace.
+	MaxRecovered int
+
+	// Observer, if set, is notified of every panic caught by this Catcher,
+	// after the observers registered with AddObserver.
+	Observer Observer
+
+	// Filter, if set, decides which panics are caught. Panics for which it
+	// returns false are not recorded, and are re-raised immediately with
+	// their original value in the goroutine that panicked.
+	Filter func(value any) bool
+
 	recovered atomic.Pointer[Recovered]
+	count     atomic.Int64
+
+	// mu protects all, which is only used if MaxRecovered > 1.
+	mu  sync.Mutex
+	all []*Recovered
 }
 
 // Try executes f, catching any panic it might spawn. It is safe
 // to call from multiple goroutines simultaneously.
 func (p *Catcher) Try(f func()) {
-	defer p.tryRecover()
+	defer p.tryRecover(nil)
+	f()
+}
+
+// TryFrom is like Try, but records spawn as the callers of the goroutine
+// that launched f, as returned by runtime.Callers. If f panics, they are
+// available as the SpawnCallers of the recovered panic.
+func (p *Catcher) TryFrom(spawn []uintptr, f func()) {
+	defer p.tryRecover(spawn)
 	f()
 }
 
-func (p *Catcher) tryRecover() {
+func (p *Catcher) tryRecover(spawn []uintptr) {
 	if val := recover(); val != nil {
+		if p.Filter != nil && !p.Filter(val) {
+			panic(val)
+		}
 		rp := NewRecovered(1, val)
-		p.recovered.CompareAndSwap(nil, &rp)
+		rp.SpawnCallers = spawn
+		p.record(&rp)
 	}
 }
 
+func (p *Catcher) record(rp *Recovered) {
+	if p.MaxRecovered > 1 {
+		// Store the first panic under the lock too, so that it is also the
+		// first one in all.
+		p.mu.Lock()
+		p.recovered.CompareAndSwap(nil, rp)
+		if len(p.all) < p.MaxRecovered {
+			p.all = append(p.all, rp)
+		}
+		p.mu.Unlock()
+	} else {
+		p.recovered.CompareAndSwap(nil, rp)
+	}
+	p.count.Add(1)
+
+	// A panic whose value is already a recovered panic is being propagated
+	// from another Catcher, which notified the observers when it caught it
+	// first. Don't report it again.
+	if unwrapRecovered(rp.Value) != nil {
+		return
+	}
+
+	// Observers are called without holding the lock so that they can't
+	// deadlock the Catcher, even if they use it themselves.
+	notifyObservers(rp, p.Observer)
+}
+
 // Repanic panics if any calls to Try caught a panic. It will panic with the
 // value of the first panic caught, wrapped in a panics.Recovered with caller
 // information.
@@ -45,6 +116,56 @@ func (p *Catcher) Recovered() *Recovered {
 	return p.recovered.Load()
 }
 
+// RecoveredAll returns the panics caught by Try, in the order they were
+// caught, or nil if no calls to Try panicked. At most MaxRecovered panics
+// are returned, or only the first one if MaxRecovered is not set; use Count
+// to find out whether any were dropped.
+func (p *Catcher) RecoveredAll() []*Recovered {
+	if p.MaxRecovered <= 1 {
+		if rp := p.Recovered(); rp != nil {
+			return []*Recovered{rp}
+		}
+		return nil
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if len(p.all) == 0 {
+		return nil
+	}
+	res := make([]*Recovered, len(p.all))
+	copy(res, p.all)
+	return res
+}
+
+// Count returns the number of panics caught by Try, including the ones that
+// were dropped because MaxRecovered was reached.
+func (p *Catcher) Count() int {
+	return int(p.count.Load())
+}
+
+// AsError returns the panics retained by the Catcher, joined into a single
+// error, or nil if no calls to Try panicked. If panics were dropped because
+// MaxRecovered was reached, the error says how many.
+func (p *Catcher) AsError() error {
+	all := p.RecoveredAll()
+	if len(all) == 0 {
+		return nil
+	}
+	count := p.Count()
+	if count <= 1 {
+		return all[0].AsError()
+	}
+	errs := make([]error, 0, len(all)+1)
+	for _, rp := range all {
+		errs = append(errs, rp.AsError())
+	}
+	if dropped := count - len(all); dropped > 0 {
+		errs = append(errs, fmt.Errorf("%d more panics were dropped", dropped))
+	}
+	return errors.Join(errs...)
+}
+
 // NewRecovered creates a panics.Recovered from a panic value and a collected
 // stacktrace. The skip parameter allows the caller to skip stack frames when
 // collecting the stacktrace. Calling with a skip of 0 means include the call to
@@ -71,11 +192,59 @@ type Recovered struct {
 	// The formatted stacktrace from the goroutine where the panic was recovered.
 	// Easier to use than Callers.
 	Stack []byte
+	// The caller list of the goroutine that launched the panicking function,
+	// if it was captured, for example with (*Catcher).TryFrom. Can be decoded
+	// with SpawnFrames.
+	SpawnCallers []uintptr
 }
 
 // String renders a human-readable formatting of the panic.
 func (p *Recovered) String() string {
-	return fmt.Sprintf("panic: %v\nstacktrace:\n%s\n", p.Value, p.Stack)
+	if len(p.SpawnCallers) == 0 {
+		return fmt.Sprintf("panic: %v\nstacktrace:\n%s\n", p.Value, p.Stack)
+	}
+	var spawn strings.Builder
+	for _, f := range p.SpawnFrames() {
+		spawn.WriteString(f.String())
+		spawn.WriteByte('\n')
+	}
+	return fmt.Sprintf("panic: %v\nstacktrace:\n%s\nspawned by:\n%s", p.Value, p.Stack, spawn.String())
+}
+
+// Chain returns every layer of a panic that was recovered and propagated
+// several times, starting with p and ending with the panic that was raised
+// first. A layer is added for each panic whose value is itself a *Recovered
+// or an *ErrRecovered, as happens when, for example, a pool is waited on in
+// a goroutine of another pool.
+func (p *Recovered) Chain() []*Recovered {
+	var chain []*Recovered
+	for cur := p; cur != nil; cur = unwrapRecovered(cur.Value) {
+		chain = append(chain, cur)
+	}
+	return chain
+}
+
+// Original returns the innermost layer of a panic that was recovered and
+// propagated several times, which holds the original panic value and the
+// stacktrace of the goroutine that raised it. It returns p if the panic was
+// not propagated.
+func (p *Recovered) Original() *Recovered {
+	if p == nil {
+		return nil
+	}
+	chain := p.Chain()
+	return chain[len(chain)-1]
+}
+
+func unwrapRecovered(value any) *Recovered {
+	switch v := value.(type) {
+	case *Recovered:
+		return v
+	case *ErrRecovered:
+		return &v.Recovered
+	default:
+		return nil
+	}
 }
 
 // AsError casts the panic into an error implementation. The implementation
@@ -94,7 +263,15 @@ var _ error = (*ErrRecovered)(nil)
 
 func (p *ErrRecovered) Error() string { return p.String() }
 
+// Unwrap returns the cause of the panic, if the panic was provided an error.
+// If the panic value is itself a recovered panic, which happens when a
+// recovered panic is propagated through several layers, the error of that
+// inner panic is returned instead, so that errors.Is and errors.As reach the
+// original panic value.
 func (p *ErrRecovered) Unwrap() error {
+	if inner, ok := p.Value.(*Recovered); ok {
+		return inner.AsError()
+	}
 	if err, ok := p.Value.(error); ok {
 		return err
 	}
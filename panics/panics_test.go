@@ -7,6 +7,7 @@ import (
 	"sync"
 	"testing"
 
+	"github.com/sourcegraph/conc"
 	"github.com/sourcegraph/conc/panics"
 
 	"github.com/stretchr/testify/assert"
@@ -123,6 +124,20 @@ func TestCatcher(t *testing.T) {
 		require.NotPanics(t, pc.Repanic)
 	})
 
+	t.Run("filter", func(t *testing.T) {
+		t.Parallel()
+		pc := panics.Catcher{Filter: func(value any) bool {
+			return value != "fatal"
+		}}
+		pc.Try(func() { panic("recoverable") })
+		require.Equal(t, "recoverable", pc.Recovered().Value)
+
+		require.PanicsWithValue(t, "fatal", func() {
+			pc.Try(func() { panic("fatal") })
+		})
+		require.Equal(t, 1, pc.Count())
+	})
+
 	t.Run("is goroutine safe", func(t *testing.T) {
 		t.Parallel()
 		var wg sync.WaitGroup
@@ -145,6 +160,87 @@ func TestCatcher(t *testing.T) {
 	})
 }
 
+func TestCatcherAll(t *testing.T) {
+	t.Parallel()
+
+	t.Run("no panics", func(t *testing.T) {
+		t.Parallel()
+		var pc panics.Catcher
+		pc.Try(func() {})
+		require.Nil(t, pc.RecoveredAll())
+		require.Zero(t, pc.Count())
+		require.NoError(t, pc.AsError())
+	})
+
+	t.Run("single panic", func(t *testing.T) {
+		t.Parallel()
+		err1 := errors.New("SOS")
+		var pc panics.Catcher
+		pc.Try(func() { panic(err1) })
+		require.Len(t, pc.RecoveredAll(), 1)
+		require.Equal(t, 1, pc.Count())
+		require.ErrorIs(t, pc.AsError(), err1)
+		require.Equal(t, pc.Recovered().AsError().Error(), pc.AsError().Error())
+	})
+
+	t.Run("all panics are retained", func(t *testing.T) {
+		t.Parallel()
+		err1, err2 := errors.New("SOS"), errors.New("mayday")
+		pc := panics.Catcher{MaxRecovered: panics.DefaultMaxRecovered}
+		pc.Try(func() { panic(err1) })
+		pc.Try(func() {})
+		pc.Try(func() { panic(err2) })
+
+		all := pc.RecoveredAll()
+		require.Len(t, all, 2)
+		require.Equal(t, err1, all[0].Value)
+		require.Equal(t, err2, all[1].Value)
+		require.Equal(t, all[0], pc.Recovered())
+		require.Equal(t, 2, pc.Count())
+
+		err := pc.AsError()
+		require.ErrorIs(t, err, err1)
+		require.ErrorIs(t, err, err2)
+	})
+
+	t.Run("cap", func(t *testing.T) {
+		t.Parallel()
+		pc := panics.Catcher{MaxRecovered: 3}
+		var wg sync.WaitGroup
+		for i := 0; i < 10; i++ {
+			i := i
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				pc.Try(func() { panic(i) })
+			}()
+		}
+		wg.Wait()
+		require.Len(t, pc.RecoveredAll(), 3)
+		require.Equal(t, 10, pc.Count())
+		require.Equal(t, pc.RecoveredAll()[0], pc.Recovered())
+		require.Contains(t, pc.AsError().Error(), "7 more panics were dropped")
+	})
+
+	t.Run("only the first is retained by default", func(t *testing.T) {
+		t.Parallel()
+		err1, err2 := errors.New("SOS"), errors.New("mayday")
+		var pc panics.Catcher
+		pc.Try(func() { panic(err1) })
+		pc.Try(func() { panic(err2) })
+
+		all := pc.RecoveredAll()
+		require.Len(t, all, 1)
+		require.Equal(t, err1, all[0].Value)
+		require.Equal(t, 2, pc.Count())
+
+		err := pc.AsError()
+		require.ErrorIs(t, err, err1)
+		require.NotErrorIs(t, err, err2)
+		require.Contains(t, err.Error(), "1 more panics were dropped")
+	})
+}
+
 func TestRecoveredAsError(t *testing.T) {
 	t.Parallel()
 	t.Run("as error is nil", func(t *testing.T) {
@@ -169,3 +265,54 @@ func TestRecoveredAsError(t *testing.T) {
 		assert.NotNil(t, err)
 	})
 }
+
+func TestRecoveredChain(t *testing.T) {
+	t.Parallel()
+
+	err1 := errors.New("SOS")
+
+	t.Run("not nested", func(t *testing.T) {
+		t.Parallel()
+		recovered := panics.Try(func() { panic(err1) })
+		require.Equal(t, []*panics.Recovered{recovered}, recovered.Chain())
+		require.Equal(t, recovered, recovered.Original())
+	})
+
+	t.Run("nil", func(t *testing.T) {
+		t.Parallel()
+		var recovered *panics.Recovered
+		require.Nil(t, recovered.Chain())
+		require.Nil(t, recovered.Original())
+	})
+
+	t.Run("nested", func(t *testing.T) {
+		t.Parallel()
+		inner := panics.Try(func() { panic(err1) })
+		middle := panics.Try(func() { panic(inner) })
+		outer := panics.Try(func() { panic(middle.AsError()) })
+
+		chain := outer.Chain()
+		require.Len(t, chain, 3)
+		require.Equal(t, inner, chain[2])
+		require.Equal(t, inner, outer.Original())
+		require.Equal(t, err1, outer.Original().Value)
+
+		err := outer.AsError()
+		require.ErrorIs(t, err, err1)
+		var errRecovered *panics.ErrRecovered
+		require.ErrorAs(t, err, &errRecovered)
+	})
+
+	t.Run("through wait groups", func(t *testing.T) {
+		t.Parallel()
+		var outer, inner conc.WaitGroup
+		outer.Go(func() {
+			inner.Go(func() { panic(err1) })
+			inner.Wait()
+		})
+		recovered := outer.WaitAndRecover()
+		require.Len(t, recovered.Chain(), 2)
+		require.Equal(t, err1, recovered.Original().Value)
+		require.ErrorIs(t, recovered.AsError(), err1)
+	})
+}
@@ -9,3 +9,33 @@ func Try(f func()) *Recovered {
 	c.Try(f)
 	return c.Recovered()
 }
+
+// TryValue executes f, returning its result, or catching and returning any
+// panic it might spawn. If f panics, the returned value is the zero value
+// of T.
+func TryValue[T any](f func() T) (T, *Recovered) {
+	var res T
+	recovered := Try(func() { res = f() })
+	return res, recovered
+}
+
+// TryErr executes f, returning its error. If f panics, the panic is caught
+// and returned as an error, which is an *ErrRecovered.
+func TryErr(f func() error) error {
+	var err error
+	if recovered := Try(func() { err = f() }); recovered != nil {
+		return recovered.AsError()
+	}
+	return err
+}
+
+// TryOnly executes f, catching and returning any panic it might spawn whose
+// value is of type E. Panics with a value of any other type are not caught.
+func TryOnly[E any](f func()) *Recovered {
+	c := Catcher{Filter: func(value any) bool {
+		_, ok := value.(E)
+		return ok
+	}}
+	c.Try(f)
+	return c.Recovered()
+}
@@ -2,6 +2,8 @@ package panics_test
 
 import (
 	"errors"
+	"net/http"
+	"runtime"
 	"testing"
 
 	"github.com/sourcegraph/conc/panics"
@@ -33,3 +35,84 @@ func TestTry(t *testing.T) {
 		require.Nil(t, recovered)
 	})
 }
+
+func TestTryValue(t *testing.T) {
+	t.Parallel()
+
+	t.Run("value", func(t *testing.T) {
+		t.Parallel()
+		res, recovered := panics.TryValue(func() int { return 42 })
+		require.Nil(t, recovered)
+		require.Equal(t, 42, res)
+	})
+
+	t.Run("panics", func(t *testing.T) {
+		t.Parallel()
+		res, recovered := panics.TryValue(func() int { panic("SOS") })
+		require.NotNil(t, recovered)
+		require.Equal(t, "SOS", recovered.Value)
+		require.Zero(t, res)
+	})
+}
+
+func TestTryErr(t *testing.T) {
+	t.Parallel()
+
+	err1 := errors.New("SOS")
+
+	t.Run("no error", func(t *testing.T) {
+		t.Parallel()
+		require.NoError(t, panics.TryErr(func() error { return nil }))
+	})
+
+	t.Run("error", func(t *testing.T) {
+		t.Parallel()
+		err := panics.TryErr(func() error { return err1 })
+		require.Equal(t, err1, err)
+	})
+
+	t.Run("panics", func(t *testing.T) {
+		t.Parallel()
+		err := panics.TryErr(func() error { panic(err1) })
+		var errRecovered *panics.ErrRecovered
+		require.ErrorAs(t, err, &errRecovered)
+		require.ErrorIs(t, err, err1)
+	})
+}
+
+func TestTryOnly(t *testing.T) {
+	t.Parallel()
+
+	t.Run("matching panic is caught", func(t *testing.T) {
+		t.Parallel()
+		recovered := panics.TryOnly[error](func() { panic(http.ErrAbortHandler) })
+		require.ErrorIs(t, recovered.AsError(), http.ErrAbortHandler)
+	})
+
+	t.Run("other panic is propagated", func(t *testing.T) {
+		t.Parallel()
+		defer func() {
+			require.Equal(t, "not an error", recover())
+		}()
+		panics.TryOnly[error](func() { panic("not an error") })
+		t.Fatal("the panic should have been propagated")
+	})
+
+	t.Run("runtime error is propagated", func(t *testing.T) {
+		t.Parallel()
+		defer func() {
+			_, ok := recover().(runtime.Error)
+			require.True(t, ok)
+		}()
+		panics.TryOnly[string](func() {
+			var m map[string]int
+			m["boom"] = 1
+		})
+		t.Fatal("the panic should have been propagated")
+	})
+
+	t.Run("no panic", func(t *testing.T) {
+		t.Parallel()
+		require.Nil(t, panics.TryOnly[error](func() {}))
+	})
+}
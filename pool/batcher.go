@@ -0,0 +1,229 @@
+package pool
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/sourcegraph/conc/panics"
+)
+
+// NewBatcher creates a new Batcher that passes batches of items of type T to
+// handler. The handler must return either an error, or exactly one result
+// per item in the batch, in the same order as the items.
+func NewBatcher[T, R any](handler func([]T) ([]R, error)) *Batcher[T, R] {
+	return &Batcher[T, R]{
+		handler: handler,
+	}
+}
+
+// Batcher collects items submitted from any number of goroutines into
+// batches, and runs a handler on each batch in a pool of goroutines.
+//
+// A batch is handed off as soon as it reaches the size configured with
+// WithMaxSize(), or once the oldest item in it has waited for the delay
+// configured with WithMaxDelay(), whichever comes first. Wait() hands off any
+// remaining items, so a Batcher with neither limit configured runs a single
+// batch on Wait().
+//
+// Items are submitted with Go(), or with Do() to wait for the item's result,
+// which requires WithMaxDelay(). Once all your items have been submitted, you
+// must call Wait() to flush the last batch, clean up any spawned goroutines
+// and propagate any panics.
+//
+// The configuration methods (With*) will panic if they are used after calling
+// Go() or Do() for the first time.
+type Batcher[T, R any] struct {
+	pool    ErrorPool
+	handler func([]T) ([]R, error)
+
+	maxSize  int
+	maxDelay time.Duration
+	started  bool
+
+	mu      sync.Mutex
+	items   []T
+	waiters []chan batchResult[R]
+	// gen is incremented every time a batch is flushed so that a timer for
+	// an already flushed batch can tell it is stale.
+	gen    int
+	timer  *time.Timer
+	timers sync.WaitGroup
+}
+
+type batchResult[R any] struct {
+	res R
+	err error
+}
+
+// Go submits an item to the current batch. If the batch is full and all
+// goroutines in the pool are busy, a call to Go() will block until the batch
+// can be started. Errors from the handler are returned by Wait().
+func (b *Batcher[T, R]) Go(item T) {
+	b.add(item, nil)
+}
+
+// Do submits an item to the current batch, and waits for the handler to
+// process that batch. It returns the item's result, or the error returned by
+// the handler for the batch.
+//
+// Since Do blocks until its batch is handed off, it panics if no delay was
+// configured with WithMaxDelay(). Otherwise, the items of a last batch that
+// isn't full would wait for Wait(), which is usually only called once every
+// call to Do has returned.
+func (b *Batcher[T, R]) Do(item T) (R, error) {
+	if b.maxDelay == 0 {
+		panic("batcher must have a max delay to use Do()")
+	}
+	ch := make(chan batchResult[R], 1)
+	b.add(item, ch)
+	res := <-ch
+	return res.res, res.err
+}
+
+// Wait hands off the last batch, waits for all batches to be processed,
+// propagates any panics, and returns any errors returned by the handler.
+func (b *Batcher[T, R]) Wait() error {
+	b.mu.Lock()
+	items, waiters := b.take()
+	b.mu.Unlock()
+	b.submit(items, waiters)
+
+	// Wait for any timer that fired concurrently. It will find that its
+	// batch has already been flushed.
+	b.timers.Wait()
+
+	return b.pool.Wait()
+}
+
+// WithMaxSize configures the batcher to hand off a batch as soon as it holds
+// n items. Defaults to unlimited. Panics if n < 1.
+func (b *Batcher[T, R]) WithMaxSize(n int) *Batcher[T, R] {
+	b.panicIfInitialized()
+	if n < 1 {
+		panic("max batch size must be greater than zero")
+	}
+	b.maxSize = n
+	return b
+}
+
+// WithMaxDelay configures the batcher to hand off a batch once its oldest
+// item has waited for d. Defaults to unlimited. Panics if d <= 0.
+func (b *Batcher[T, R]) WithMaxDelay(d time.Duration) *Batcher[T, R] {
+	b.panicIfInitialized()
+	if d <= 0 {
+		panic("max batch delay must be greater than zero")
+	}
+	b.maxDelay = d
+	return b
+}
+
+// WithMaxGoroutines limits the number of batches handled concurrently.
+// Defaults to unlimited. Panics if n < 1.
+func (b *Batcher[T, R]) WithMaxGoroutines(n int) *Batcher[T, R] {
+	b.panicIfInitialized()
+	b.pool.WithMaxGoroutines(n)
+	return b
+}
+
+func (b *Batcher[T, R]) add(item T, waiter chan batchResult[R]) {
+	b.mu.Lock()
+
+	b.started = true
+	b.items = append(b.items, item)
+	b.waiters = append(b.waiters, waiter)
+
+	if b.maxSize > 0 && len(b.items) >= b.maxSize {
+		items, waiters := b.take()
+		b.mu.Unlock()
+		// Submit outside of the lock, since it may block until a goroutine
+		// is available, and other items can be added in the meantime.
+		b.submit(items, waiters)
+		return
+	}
+
+	if len(b.items) == 1 && b.maxDelay > 0 {
+		// This is the first item of a new batch, so start its timer.
+		gen := b.gen
+		b.timers.Add(1)
+		b.timer = time.AfterFunc(b.maxDelay, func() {
+			defer b.timers.Done()
+			b.mu.Lock()
+			var (
+				items   []T
+				waiters []chan batchResult[R]
+			)
+			if b.gen == gen {
+				items, waiters = b.take()
+			}
+			b.mu.Unlock()
+			b.submit(items, waiters)
+		})
+	}
+	b.mu.Unlock()
+}
+
+// take removes the current batch from the batcher and stops its timer. It
+// must be called with b.mu held.
+func (b *Batcher[T, R]) take() ([]T, []chan batchResult[R]) {
+	if len(b.items) == 0 {
+		return nil, nil
+	}
+
+	if b.timer != nil && b.timer.Stop() {
+		// The timer will never fire, so it won't mark itself done.
+		b.timers.Done()
+	}
+	b.timer = nil
+
+	items, waiters := b.items, b.waiters
+	b.items, b.waiters = nil, nil
+	b.gen++
+	return items, waiters
+}
+
+// submit hands off a batch returned by take to the pool. It must be called
+// without b.mu held.
+func (b *Batcher[T, R]) submit(items []T, waiters []chan batchResult[R]) {
+	if len(items) == 0 {
+		return
+	}
+
+	b.pool.Go(func() error {
+		res, err := b.run(items, waiters)
+		for i, w := range waiters {
+			if w != nil {
+				w <- batchResult[R]{res: res[i], err: err}
+			}
+		}
+		return err
+	})
+}
+
+// run calls the handler on a batch, making sure that the waiters are
+// released even if the handler panics.
+func (b *Batcher[T, R]) run(items []T, waiters []chan batchResult[R]) (res []R, err error) {
+	recovered := panics.Try(func() { res, err = b.handler(items) })
+	if recovered != nil {
+		for _, w := range waiters {
+			if w != nil {
+				w <- batchResult[R]{err: recovered.AsError()}
+			}
+		}
+		panic(recovered)
+	}
+
+	if err == nil && len(res) != len(items) {
+		err = fmt.Errorf("batch handler returned %d results for %d items", len(res), len(items))
+	}
+	if err != nil {
+		res = make([]R, len(items))
+	}
+	return res, err
+}
+
+func (b *Batcher[T, R]) panicIfInitialized() {
+	if b.started {
+		panic("batcher can not be reconfigured after calling Go() for the first time")
+	}
+}
@@ -0,0 +1,220 @@
+package pool_test
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/sourcegraph/conc"
+	"github.com/sourcegraph/conc/panics"
+	"github.com/sourcegraph/conc/pool"
+
+	"github.com/stretchr/testify/require"
+)
+
+func ExampleBatcher() {
+	b := pool.NewBatcher(func(batch []int) ([]int, error) {
+		fmt.Println(batch)
+		res := make([]int, len(batch))
+		for i, v := range batch {
+			res[i] = v * 2
+		}
+		return res, nil
+	}).WithMaxSize(3).WithMaxGoroutines(1)
+
+	for i := 0; i < 7; i++ {
+		b.Go(i)
+	}
+	_ = b.Wait()
+	// Output:
+	// [0 1 2]
+	// [3 4 5]
+	// [6]
+}
+
+func TestBatcher(t *testing.T) {
+	t.Parallel()
+
+	identity := func(batch []int) ([]int, error) { return batch, nil }
+
+	t.Run("flushes on max size", func(t *testing.T) {
+		t.Parallel()
+		var mu sync.Mutex
+		var sizes []int
+		b := pool.NewBatcher(func(batch []int) ([]int, error) {
+			mu.Lock()
+			sizes = append(sizes, len(batch))
+			mu.Unlock()
+			return batch, nil
+		}).WithMaxSize(10)
+		for i := 0; i < 95; i++ {
+			b.Go(i)
+		}
+		require.NoError(t, b.Wait())
+		require.ElementsMatch(t, []int{10, 10, 10, 10, 10, 10, 10, 10, 10, 5}, sizes)
+	})
+
+	t.Run("flushes on max delay", func(t *testing.T) {
+		t.Parallel()
+		b := pool.NewBatcher(identity).WithMaxDelay(time.Millisecond)
+		res, err := b.Do(42)
+		require.NoError(t, err)
+		require.Equal(t, 42, res)
+		require.NoError(t, b.Wait())
+	})
+
+	t.Run("results fan back to submitters", func(t *testing.T) {
+		t.Parallel()
+		b := pool.NewBatcher(func(batch []int) ([]string, error) {
+			res := make([]string, len(batch))
+			for i, v := range batch {
+				res[i] = fmt.Sprint(v)
+			}
+			return res, nil
+		}).WithMaxSize(8).WithMaxDelay(time.Millisecond)
+
+		var wg conc.WaitGroup
+		var wrong atomic.Int64
+		for i := 0; i < 100; i++ {
+			i := i
+			wg.Go(func() {
+				res, err := b.Do(i)
+				if err != nil || res != fmt.Sprint(i) {
+					wrong.Add(1)
+				}
+			})
+		}
+		wg.Wait()
+		require.NoError(t, b.Wait())
+		require.Equal(t, int64(0), wrong.Load())
+	})
+
+	t.Run("errors fan back to submitters", func(t *testing.T) {
+		t.Parallel()
+		err1 := errors.New("err1")
+		b := pool.NewBatcher(func(batch []int) ([]int, error) {
+			return nil, err1
+		}).WithMaxSize(2).WithMaxDelay(time.Millisecond)
+
+		var wg conc.WaitGroup
+		errs := make([]error, 2)
+		for i := 0; i < 2; i++ {
+			i := i
+			wg.Go(func() {
+				_, errs[i] = b.Do(1)
+			})
+		}
+		wg.Wait()
+		for _, err := range errs {
+			require.ErrorIs(t, err, err1)
+		}
+		require.ErrorIs(t, b.Wait(), err1)
+	})
+
+	t.Run("do panics without max delay", func(t *testing.T) {
+		t.Parallel()
+		b := pool.NewBatcher(identity).WithMaxSize(2)
+		require.Panics(t, func() { _, _ = b.Do(1) })
+		require.NoError(t, b.Wait())
+	})
+
+	t.Run("wrong number of results is an error", func(t *testing.T) {
+		t.Parallel()
+		b := pool.NewBatcher(func(batch []int) ([]int, error) {
+			return batch[:1], nil
+		})
+		b.Go(1)
+		b.Go(2)
+		require.Error(t, b.Wait())
+	})
+
+	t.Run("wait flushes", func(t *testing.T) {
+		t.Parallel()
+		var count atomic.Int64
+		b := pool.NewBatcher(func(batch []int) ([]int, error) {
+			count.Add(int64(len(batch)))
+			return batch, nil
+		}).WithMaxSize(100).WithMaxDelay(time.Hour)
+		for i := 0; i < 10; i++ {
+			b.Go(i)
+		}
+		require.NoError(t, b.Wait())
+		require.Equal(t, int64(10), count.Load())
+	})
+
+	t.Run("panic is propagated", func(t *testing.T) {
+		t.Parallel()
+		b := pool.NewBatcher(func(batch []int) ([]int, error) {
+			panic("oh no")
+		})
+		b.Go(1)
+		require.Panics(t, func() { _ = b.Wait() })
+	})
+
+	t.Run("panic is returned to submitters", func(t *testing.T) {
+		t.Parallel()
+		b := pool.NewBatcher(func(batch []int) ([]int, error) {
+			panic("oh no")
+		}).WithMaxDelay(time.Millisecond)
+		_, err := b.Do(1)
+		var recovered *panics.ErrRecovered
+		require.ErrorAs(t, err, &recovered)
+		require.Equal(t, "oh no", recovered.Value)
+		require.Panics(t, func() { _ = b.Wait() })
+	})
+
+	t.Run("items can be added while a batch waits for a goroutine", func(t *testing.T) {
+		t.Parallel()
+		started, release := make(chan struct{}), make(chan struct{})
+		var once sync.Once
+		var count atomic.Int64
+		b := pool.NewBatcher(func(batch []int) ([]int, error) {
+			once.Do(func() { close(started) })
+			<-release
+			count.Add(int64(len(batch)))
+			return batch, nil
+		}).WithMaxSize(2).WithMaxGoroutines(1)
+
+		// The first batch occupies the only goroutine, and the second one
+		// blocks until it is done.
+		b.Go(1)
+		b.Go(2)
+		<-started
+		var wg conc.WaitGroup
+		wg.Go(func() {
+			b.Go(3)
+			b.Go(4)
+		})
+		// Give the second batch time to block in the pool.
+		time.Sleep(10 * time.Millisecond)
+
+		// Adding to the next batch must not wait for the blocked one.
+		added := make(chan struct{})
+		go func() {
+			defer close(added)
+			b.Go(5)
+		}()
+		select {
+		case <-added:
+		case <-time.After(time.Second):
+			t.Error("adding an item blocked on a batch waiting for a goroutine")
+		}
+
+		close(release)
+		wg.Wait()
+		<-added
+		require.NoError(t, b.Wait())
+		require.Equal(t, int64(5), count.Load())
+	})
+
+	t.Run("panics on configuration after go", func(t *testing.T) {
+		t.Parallel()
+		b := pool.NewBatcher(identity)
+		b.Go(1)
+		require.Panics(t, func() { b.WithMaxSize(10) })
+		require.NoError(t, b.Wait())
+	})
+}
@@ -2,6 +2,8 @@ package pool
 
 import (
 	"context"
+
+	"github.com/sourcegraph/conc"
 )
 
 // ContextPool is a pool that runs tasks that take a context.
@@ -49,6 +51,26 @@ func (p *ContextPool) Go(f func(ctx context.Context) error) {
 	})
 }
 
+// GoNamed submits a task to the pool, like Go, and names it. The context
+// passed to f carries the conc.PprofLabel label, so it is inherited by
+// goroutines started with pprof.Do. See (*Pool).GoNamed.
+func (p *ContextPool) GoNamed(name string, f func(ctx context.Context) error) {
+	pc := callerPC()
+	p.Go(func(ctx context.Context) error {
+		var err error
+		p.errorPool.pool.runNamed(ctx, name, pc, func(ctx context.Context) {
+			err = f(ctx)
+		})
+		return err
+	})
+}
+
+// Running returns the tasks submitted with GoNamed that are currently
+// running. See (*Pool).Running.
+func (p *ContextPool) Running() []conc.RunningGoroutine {
+	return p.errorPool.Running()
+}
+
 // Wait cleans up all spawned goroutines, propagates any panics, and
 // returns an error if any of the tasks errored.
 func (p *ContextPool) Wait() error {
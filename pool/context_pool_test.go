@@ -4,11 +4,13 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"runtime/pprof"
 	"strconv"
 	"sync/atomic"
 	"testing"
 	"time"
 
+	"github.com/sourcegraph/conc"
 	"github.com/sourcegraph/conc/pool"
 
 	"github.com/stretchr/testify/assert"
@@ -43,6 +45,39 @@ func TestContextPool(t *testing.T) {
 	err2 := errors.New("err2")
 	bgctx := context.Background()
 
+	t.Run("named", func(t *testing.T) {
+		t.Parallel()
+		g := pool.New().WithContext(bgctx)
+		g.GoNamed("labeled", func(ctx context.Context) error {
+			if label, _ := pprof.Label(ctx, conc.PprofLabel); label != "labeled" {
+				return fmt.Errorf("unexpected label %q", label)
+			}
+			return err1
+		})
+		require.ErrorIs(t, g.Wait(), err1)
+	})
+
+	t.Run("running", func(t *testing.T) {
+		t.Parallel()
+		g := pool.New().WithContext(bgctx).WithCancelOnError()
+		started := make(chan struct{})
+		g.GoNamed("stuck", func(ctx context.Context) error {
+			close(started)
+			<-ctx.Done()
+			return nil
+		})
+		<-started
+
+		running := g.Running()
+		require.Len(t, running, 1)
+		require.Equal(t, "stuck", running[0].Name)
+		require.Contains(t, running[0].Location.Function, "TestContextPool")
+
+		g.Go(func(context.Context) error { return err1 })
+		require.ErrorIs(t, g.Wait(), err1)
+		require.Empty(t, g.Running())
+	})
+
 	t.Run("panics on configuration after init", func(t *testing.T) {
 		t.Run("before wait", func(t *testing.T) {
 			t.Parallel()
@@ -4,6 +4,8 @@ import (
 	"context"
 	"errors"
 	"sync"
+
+	"github.com/sourcegraph/conc"
 )
 
 // ErrorPool is a pool that runs tasks that may return an error.
@@ -30,6 +32,23 @@ func (p *ErrorPool) Go(f func() error) {
 	})
 }
 
+// GoNamed submits a task to the pool, like Go, and names it. See
+// (*Pool).GoNamed.
+func (p *ErrorPool) GoNamed(name string, f func() error) {
+	pc := callerPC()
+	p.pool.Go(func() {
+		p.pool.runNamed(context.Background(), name, pc, func(context.Context) {
+			p.addErr(f())
+		})
+	})
+}
+
+// Running returns the tasks submitted with GoNamed that are currently
+// running. See (*Pool).Running.
+func (p *ErrorPool) Running() []conc.RunningGoroutine {
+	return p.pool.Running()
+}
+
 // Wait cleans up any spawned goroutines, propagating any panics and
 // returning any errors from tasks.
 func (p *ErrorPool) Wait() error {
@@ -36,6 +36,20 @@ func TestErrorPool(t *testing.T) {
 	err1 := errors.New("err1")
 	err2 := errors.New("err2")
 
+	t.Run("named", func(t *testing.T) {
+		t.Parallel()
+		g := pool.New().WithErrors()
+		g.GoNamed("first", func() error { return err1 })
+		g.GoNamed("second", func() error {
+			if len(g.Running()) == 0 {
+				return errors.New("task is not listed as running")
+			}
+			return nil
+		})
+		require.ErrorIs(t, g.Wait(), err1)
+		require.Empty(t, g.Running())
+	})
+
 	t.Run("panics on configuration after init", func(t *testing.T) {
 		t.Run("before wait", func(t *testing.T) {
 			t.Parallel()
@@ -2,9 +2,13 @@ package pool
 
 import (
 	"context"
+	"runtime"
+	"runtime/pprof"
 	"sync"
 
 	"github.com/sourcegraph/conc"
+	"github.com/sourcegraph/conc/internal/registry"
+	"github.com/sourcegraph/conc/panics"
 )
 
 // New creates a new Pool.
@@ -32,39 +36,63 @@ type Pool struct {
 	limiter  limiter
 	tasks    chan func()
 	initOnce sync.Once
+
+	panicObserver panics.Observer
+	panicFilter   func(any) bool
+
+	running registry.Registry
 }
 
 // Go submits a task to be run in the pool. If all goroutines in the pool
 // are busy, a call to Go() will block until the task can be started.
 func (p *Pool) Go(f func()) {
 	p.init()
+	submit(p, p.tasks, f, p.worker)
+}
 
-	if p.limiter == nil {
-		// No limit on the number of goroutines.
-		select {
-		case p.tasks <- f:
-			// A goroutine was available to handle the task.
-		default:
-			// No goroutine was available to handle the task.
-			// Spawn a new one and send it the task.
-			p.handle.Go(func() {
-				p.worker(f)
-			})
-		}
-	} else {
-		select {
-		case p.limiter <- struct{}{}:
-			// If we are below our limit, spawn a new worker rather
-			// than waiting for one to become available.
-			p.handle.Go(func() {
-				p.worker(f)
-			})
-		case p.tasks <- f:
-			// A worker is available and has accepted the task.
-			return
+// GoNamed submits a task to be run in the pool, like Go. While the task
+// runs, it is listed by Running with its name, and the name is set as the
+// conc.PprofLabel label of the goroutine, so that CPU and goroutine profiles
+// can be attributed to it.
+func (p *Pool) GoNamed(name string, f func()) {
+	pc := callerPC()
+	p.Go(func() {
+		p.runNamed(context.Background(), name, pc, func(context.Context) {
+			f()
+		})
+	})
+}
+
+// Running returns the tasks submitted with GoNamed that are currently
+// running, in the order they started. It is safe to call concurrently with
+// Go and Wait.
+func (p *Pool) Running() []conc.RunningGoroutine {
+	entries := p.running.Snapshot()
+	res := make([]conc.RunningGoroutine, len(entries))
+	for i, e := range entries {
+		res[i] = conc.RunningGoroutine{
+			Name:     e.Name,
+			Started:  e.Started,
+			Location: e.Location,
 		}
 	}
+	return res
+}
 
+// runNamed runs a task submitted from pc with GoNamed, registering it for
+// Running and labeling it with name.
+func (p *Pool) runNamed(ctx context.Context, name string, pc uintptr, f func(context.Context)) {
+	id := p.running.Add(name, pc)
+	defer p.running.Remove(id)
+	pprof.Do(ctx, pprof.Labels(conc.PprofLabel, name), f)
+}
+
+// callerPC returns the program counter of the caller of the function that
+// calls callerPC.
+func callerPC() uintptr {
+	var pcs [1]uintptr
+	runtime.Callers(3, pcs[:])
+	return pcs[0]
 }
 
 // Wait cleans up spawned goroutines, propagating any panics that were
@@ -97,11 +125,29 @@ func (p *Pool) WithMaxGoroutines(n int) *Pool {
 	return p
 }
 
+// WithPanicObserver configures an observer that is notified synchronously
+// whenever a task panics, rather than only when Wait propagates the panic.
+func (p *Pool) WithPanicObserver(f panics.Observer) *Pool {
+	p.panicIfInitialized()
+	p.panicObserver = f
+	return p
+}
+
+// WithPanicFilter configures which panics are caught by the pool. Panics
+// for which filter returns false are not propagated to Wait, but re-raised
+// immediately in the goroutine that panicked.
+func (p *Pool) WithPanicFilter(filter func(value any) bool) *Pool {
+	p.panicIfInitialized()
+	p.panicFilter = filter
+	return p
+}
+
 // init ensures that the pool is initialized before use. This makes the
 // zero value of the pool usable.
 func (p *Pool) init() {
 	p.initOnce.Do(func() {
 		p.tasks = make(chan func())
+		p.handle.WithPanicObserver(p.panicObserver).WithPanicFilter(p.panicFilter)
 	})
 }
 
@@ -129,7 +175,9 @@ func (p *Pool) WithErrors() *ErrorPool {
 func (p *Pool) deref() Pool {
 	p.panicIfInitialized()
 	return Pool{
-		limiter: p.limiter,
+		limiter:       p.limiter,
+		panicObserver: p.panicObserver,
+		panicFilter:   p.panicFilter,
 	}
 }
 
@@ -161,6 +209,37 @@ func (p *Pool) worker(initialFunc func()) {
 	}
 }
 
+// submit hands task to an idle worker of p through tasks, or spawns a new
+// worker for it if p is below its goroutine limit. It blocks until one of
+// the two is possible.
+func submit[T any](p *Pool, tasks chan T, task T, worker func(T)) {
+	if p.limiter == nil {
+		// No limit on the number of goroutines.
+		select {
+		case tasks <- task:
+			// A goroutine was available to handle the task.
+		default:
+			// No goroutine was available to handle the task.
+			// Spawn a new one and send it the task.
+			p.handle.Go(func() {
+				worker(task)
+			})
+		}
+	} else {
+		select {
+		case p.limiter <- struct{}{}:
+			// If we are below our limit, spawn a new worker rather
+			// than waiting for one to become available.
+			p.handle.Go(func() {
+				worker(task)
+			})
+		case tasks <- task:
+			// A worker is available and has accepted the task.
+			return
+		}
+	}
+}
+
 type limiter chan struct{}
 
 func (l limiter) limit() int {
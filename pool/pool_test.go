@@ -3,10 +3,12 @@ package pool_test
 import (
 	"fmt"
 	"strconv"
+	"strings"
 	"sync/atomic"
 	"testing"
 	"time"
 
+	"github.com/sourcegraph/conc/panics"
 	"github.com/sourcegraph/conc/pool"
 
 	"github.com/stretchr/testify/require"
@@ -103,6 +105,66 @@ func TestPool(t *testing.T) {
 		require.Panics(t, g.Wait)
 	})
 
+	t.Run("named", func(t *testing.T) {
+		t.Parallel()
+		g := pool.New().WithMaxGoroutines(2)
+		var completed atomic.Int64
+		for i := 0; i < 10; i++ {
+			g.GoNamed(fmt.Sprintf("task-%d", i), func() {
+				completed.Add(1)
+			})
+		}
+		g.Wait()
+		require.Equal(t, int64(10), completed.Load())
+	})
+
+	t.Run("running", func(t *testing.T) {
+		t.Parallel()
+		g := pool.New()
+		started, release := make(chan struct{}), make(chan struct{})
+		g.GoNamed("stuck", func() {
+			close(started)
+			<-release
+		})
+		g.Go(func() { <-release })
+		<-started
+
+		running := g.Running()
+		require.Len(t, running, 1)
+		require.Equal(t, "stuck", running[0].Name)
+		require.Contains(t, running[0].Location.Function, "TestPool")
+		require.True(t, strings.HasSuffix(running[0].Location.File, "pool_test.go"))
+
+		close(release)
+		g.Wait()
+		require.Empty(t, g.Running())
+	})
+
+	t.Run("panic observer", func(t *testing.T) {
+		t.Parallel()
+		var observed atomic.Int64
+		g := pool.New().WithPanicObserver(func(*panics.Recovered, panics.Goroutine) {
+			observed.Add(1)
+		}).WithErrors()
+		for i := 0; i < 3; i++ {
+			g.Go(func() error { panic("super bad thing") })
+		}
+		require.Panics(t, func() { _ = g.Wait() })
+		require.Equal(t, int64(3), observed.Load())
+	})
+
+	t.Run("panic filter", func(t *testing.T) {
+		t.Parallel()
+		var filtered atomic.Int64
+		g := pool.New().WithPanicFilter(func(value any) bool {
+			filtered.Add(1)
+			return value == "super bad thing"
+		}).WithMaxGoroutines(1)
+		g.Go(func() { panic("super bad thing") })
+		require.Panics(t, g.Wait)
+		require.Equal(t, int64(1), filtered.Load())
+	})
+
 	t.Run("panics do not exhaust goroutines", func(t *testing.T) {
 		t.Parallel()
 		g := pool.New().WithMaxGoroutines(2)
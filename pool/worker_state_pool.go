@@ -0,0 +1,142 @@
+package pool
+
+import (
+	"context"
+	"sync"
+
+	"github.com/sourcegraph/conc"
+	"github.com/sourcegraph/conc/panics"
+)
+
+// WithWorkerState creates a new WorkerStatePool. Each goroutine in the pool
+// calls newState once, when it starts, and passes the resulting state to
+// every task it runs.
+func WithWorkerState[S any](newState func() S) *WorkerStatePool[S] {
+	return &WorkerStatePool[S]{
+		newState: newState,
+	}
+}
+
+// WorkerStatePool is a pool of goroutines used to execute tasks concurrently,
+// where each goroutine owns a state value that is reused across the tasks it
+// runs. Since a state is only ever used by one task at a time, it can hold
+// scratch space such as buffers, parsers or connections without any
+// synchronization.
+//
+// Like Pool, goroutines are started lazily, so states are only created when
+// a new goroutine is needed to run a task. If a teardown function is
+// configured with WithTeardown, it is called with each state when its
+// goroutine exits, including when a task panics.
+//
+// The configuration methods (With*) will panic if they are used after calling
+// Go() for the first time.
+type WorkerStatePool[S any] struct {
+	// pool holds the configuration and goroutines of the pool. Its own task
+	// channel is unused, since tasks take the state of their worker.
+	pool     Pool
+	tasks    chan func(S)
+	initOnce sync.Once
+
+	newState func() S
+	teardown func(S)
+}
+
+// Go submits a task to be run in the pool. If all goroutines in the pool
+// are busy, a call to Go() will block until the task can be started.
+func (p *WorkerStatePool[S]) Go(f func(S)) {
+	p.init()
+	submit(&p.pool, p.tasks, f, p.worker)
+}
+
+// GoNamed submits a task to be run in the pool, like Go. While the task
+// runs, it is listed by Running with its name, and the name is set as the
+// conc.PprofLabel label of the goroutine.
+func (p *WorkerStatePool[S]) GoNamed(name string, f func(S)) {
+	pc := callerPC()
+	p.Go(func(state S) {
+		p.pool.runNamed(context.Background(), name, pc, func(context.Context) {
+			f(state)
+		})
+	})
+}
+
+// Running returns the tasks submitted with GoNamed that are currently
+// running, in the order they started.
+func (p *WorkerStatePool[S]) Running() []conc.RunningGoroutine {
+	return p.pool.Running()
+}
+
+// Wait cleans up spawned goroutines, tearing down their states and
+// propagating any panics that were raised by a task.
+func (p *WorkerStatePool[S]) Wait() {
+	p.init()
+
+	close(p.tasks)
+
+	// After Wait() returns, reset the struct so tasks will be reinitialized on
+	// next use. This better matches the behavior of sync.WaitGroup
+	defer func() { p.initOnce = sync.Once{} }()
+
+	p.pool.Wait()
+}
+
+// MaxGoroutines returns the maximum size of the pool.
+func (p *WorkerStatePool[S]) MaxGoroutines() int {
+	return p.pool.MaxGoroutines()
+}
+
+// WithMaxGoroutines limits the number of goroutines in a pool, and hence the
+// number of states that are alive at the same time. Defaults to unlimited.
+// Panics if n < 1.
+func (p *WorkerStatePool[S]) WithMaxGoroutines(n int) *WorkerStatePool[S] {
+	p.pool.WithMaxGoroutines(n)
+	return p
+}
+
+// WithPanicObserver configures an observer that is notified synchronously
+// whenever a task panics, rather than only when Wait propagates the panic.
+func (p *WorkerStatePool[S]) WithPanicObserver(f panics.Observer) *WorkerStatePool[S] {
+	p.pool.WithPanicObserver(f)
+	return p
+}
+
+// WithPanicFilter configures which panics are caught by the pool. Panics
+// for which filter returns false are not propagated to Wait, but re-raised
+// immediately in the goroutine that panicked.
+func (p *WorkerStatePool[S]) WithPanicFilter(filter func(value any) bool) *WorkerStatePool[S] {
+	p.pool.WithPanicFilter(filter)
+	return p
+}
+
+// WithTeardown configures a function that is called with each goroutine's
+// state when the goroutine exits, for example to close a connection.
+func (p *WorkerStatePool[S]) WithTeardown(teardown func(S)) *WorkerStatePool[S] {
+	p.pool.panicIfInitialized()
+	p.teardown = teardown
+	return p
+}
+
+// init ensures that the pool is initialized before use.
+func (p *WorkerStatePool[S]) init() {
+	p.initOnce.Do(func() {
+		p.tasks = make(chan func(S))
+		p.pool.init()
+	})
+}
+
+func (p *WorkerStatePool[S]) worker(initialFunc func(S)) {
+	// The only time this matters is if the task panics.
+	// This makes it possible to spin up new workers in that case.
+	defer p.pool.limiter.release()
+
+	state := p.newState()
+	if p.teardown != nil {
+		defer p.teardown(state)
+	}
+
+	initialFunc(state)
+
+	for f := range p.tasks {
+		f(state)
+	}
+}
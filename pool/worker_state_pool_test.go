@@ -0,0 +1,136 @@
+package pool_test
+
+import (
+	"fmt"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/sourcegraph/conc/panics"
+	"github.com/sourcegraph/conc/pool"
+
+	"github.com/stretchr/testify/require"
+)
+
+func ExampleWorkerStatePool() {
+	var built atomic.Int64
+	p := pool.WithWorkerState(func() *strings.Builder {
+		return &strings.Builder{}
+	}).WithMaxGoroutines(1)
+	for i := 0; i < 3; i++ {
+		p.Go(func(b *strings.Builder) {
+			b.WriteString("conc")
+			built.Add(int64(b.Len()))
+		})
+	}
+	p.Wait()
+	fmt.Println(built.Load())
+	// Output:
+	// 24
+}
+
+func TestWorkerStatePool(t *testing.T) {
+	t.Parallel()
+
+	t.Run("states are reused and torn down", func(t *testing.T) {
+		t.Parallel()
+		var created, tornDown, shared atomic.Int64
+		type state struct{ busy atomic.Bool }
+		p := pool.WithWorkerState(func() *state {
+			created.Add(1)
+			return &state{}
+		}).WithMaxGoroutines(4).WithTeardown(func(*state) {
+			tornDown.Add(1)
+		})
+		for i := 0; i < 1000; i++ {
+			p.Go(func(s *state) {
+				if !s.busy.CompareAndSwap(false, true) {
+					shared.Add(1)
+				}
+				s.busy.Store(false)
+			})
+		}
+		p.Wait()
+		require.Equal(t, int64(0), shared.Load())
+		require.LessOrEqual(t, created.Load(), int64(1000))
+		require.Equal(t, created.Load(), tornDown.Load())
+	})
+
+	t.Run("no tasks", func(t *testing.T) {
+		t.Parallel()
+		p := pool.WithWorkerState(func() int {
+			panic("this should never be called")
+		})
+		p.Wait()
+	})
+
+	t.Run("teardown on panic", func(t *testing.T) {
+		t.Parallel()
+		var tornDown atomic.Int64
+		p := pool.WithWorkerState(func() int { return 0 }).WithTeardown(func(int) {
+			tornDown.Add(1)
+		})
+		p.Go(func(int) { panic("super bad thing happened") })
+		require.Panics(t, p.Wait)
+		require.Equal(t, int64(1), tornDown.Load())
+	})
+
+	t.Run("panics on configuration after init", func(t *testing.T) {
+		t.Parallel()
+		p := pool.WithWorkerState(func() int { return 0 })
+		p.Go(func(int) {})
+		require.Panics(t, func() { p.WithMaxGoroutines(10) })
+		require.Panics(t, func() { p.WithTeardown(func(int) {}) })
+		p.Wait()
+	})
+
+	t.Run("running", func(t *testing.T) {
+		t.Parallel()
+		p := pool.WithWorkerState(func() int { return 42 })
+		started, release := make(chan struct{}), make(chan struct{})
+		p.GoNamed("stuck", func(state int) {
+			require.Equal(t, 42, state)
+			close(started)
+			<-release
+		})
+		p.Go(func(int) { <-release })
+		<-started
+
+		running := p.Running()
+		require.Len(t, running, 1)
+		require.Equal(t, "stuck", running[0].Name)
+		require.Contains(t, running[0].Location.Function, "TestWorkerStatePool")
+
+		close(release)
+		p.Wait()
+		require.Empty(t, p.Running())
+	})
+
+	t.Run("panic observer and filter", func(t *testing.T) {
+		t.Parallel()
+		var observed, filtered atomic.Int64
+		p := pool.WithWorkerState(func() int { return 0 }).WithPanicObserver(func(*panics.Recovered, panics.Goroutine) {
+			observed.Add(1)
+		}).WithPanicFilter(func(value any) bool {
+			filtered.Add(1)
+			return true
+		})
+		p.Go(func(int) { panic("super bad thing happened") })
+		require.Panics(t, p.Wait)
+		require.Equal(t, int64(1), observed.Load())
+		require.Equal(t, int64(1), filtered.Load())
+	})
+
+	t.Run("reusable after wait", func(t *testing.T) {
+		t.Parallel()
+		var count atomic.Int64
+		p := pool.WithWorkerState(func() int { return 0 }).WithMaxGoroutines(2)
+		for round := 0; round < 2; round++ {
+			for i := 0; i < 10; i++ {
+				p.Go(func(int) { count.Add(1) })
+			}
+			p.Wait()
+		}
+		require.Equal(t, int64(20), count.Load())
+	})
+}
@@ -0,0 +1,75 @@
+package conc
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/sourcegraph/conc/internal/registry"
+	"github.com/sourcegraph/conc/panics"
+)
+
+// RunningGoroutine describes a goroutine of a WaitGroup that has not exited
+// yet, or a task of a pool that is running.
+type RunningGoroutine struct {
+	// The name the goroutine was given with GoNamed, or the empty string if
+	// it was spawned with Go.
+	Name string
+	// The time the goroutine was spawned, or the time the task started
+	// running.
+	Started time.Time
+	// The location of the call to Go or GoNamed that spawned the goroutine
+	// or submitted the task.
+	Location panics.Frame
+}
+
+// String renders the goroutine as its name, if it has one, and its spawn
+// location.
+func (g RunningGoroutine) String() string {
+	location := fmt.Sprintf("spawned at %s:%d (%s)", g.Location.File, g.Location.Line, g.Location.Function)
+	if g.Name == "" {
+		return location
+	}
+	return fmt.Sprintf("%q %s", g.Name, location)
+}
+
+// WaitError is returned when waiting for a WaitGroup is abandoned before all
+// of its goroutines exited.
+type WaitError struct {
+	// The reason waiting was abandoned, such as context.DeadlineExceeded.
+	Err error
+	// The tracked goroutines that were still running, in the order they
+	// were spawned. It only lists goroutines spawned with GoNamed, unless
+	// the WaitGroup was configured with WithTracking.
+	Running []RunningGoroutine
+}
+
+var _ error = (*WaitError)(nil)
+
+func (e *WaitError) Error() string {
+	if len(e.Running) == 0 {
+		return fmt.Sprintf("%s: goroutines still running", e.Err)
+	}
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "%s: %d tracked goroutines still running", e.Err, len(e.Running))
+	for _, g := range e.Running {
+		sb.WriteString("\n\t")
+		sb.WriteString(g.String())
+	}
+	return sb.String()
+}
+
+func (e *WaitError) Unwrap() error { return e.Err }
+
+// runningGoroutines converts the entries of a registry.
+func runningGoroutines(entries []registry.Entry) []RunningGoroutine {
+	res := make([]RunningGoroutine, len(entries))
+	for i, e := range entries {
+		res[i] = RunningGoroutine{
+			Name:     e.Name,
+			Started:  e.Started,
+			Location: e.Location,
+		}
+	}
+	return res
+}
@@ -0,0 +1,171 @@
+package conc
+
+import (
+	"context"
+	"errors"
+	"sync"
+
+	"github.com/sourcegraph/conc/panics"
+)
+
+// NewScope creates a new Scope whose context is derived from ctx.
+func NewScope(ctx context.Context) *Scope {
+	return newScope(ctx, nil)
+}
+
+// Scope is a WaitGroup for goroutines that share a context and can fail.
+// Goroutines are spawned in the Scope with Go, and are passed the Scope's
+// context. The first goroutine that returns an error or panics cancels the
+// context, so that the other goroutines can stop early. Wait waits for all
+// the goroutines, then returns all their errors, or propagates the first
+// panic.
+//
+// Scopes can be nested with Child. Canceling a Scope cancels all of its
+// children, and the errors and panics of a child also cancel its parent.
+// The errors of a child are reported to its parent as they happen, so a
+// goroutine that waits for a child scope does not need to return the
+// child's error; if it returns the error returned by the child's Wait, or an
+// error wrapping it, it is not reported twice.
+//
+// A Scope must be created with NewScope or Child, and must not be copied
+// after first use.
+type Scope struct {
+	wg     WaitGroup
+	ctx    context.Context
+	cancel context.CancelFunc
+	parent *Scope
+
+	mu       sync.Mutex
+	errs     []error
+	children []*Scope
+	waitErr  error
+}
+
+func newScope(ctx context.Context, parent *Scope) *Scope {
+	ctx, cancel := context.WithCancel(ctx)
+	return &Scope{
+		ctx:    ctx,
+		cancel: cancel,
+		parent: parent,
+	}
+}
+
+// Go spawns a new goroutine in the Scope. f is passed the Scope's context,
+// which is canceled once any goroutine of the Scope or of its children
+// returns an error or panics.
+func (s *Scope) Go(f func(ctx context.Context) error) {
+	s.wg.Go(func() {
+		defer func() {
+			if r := recover(); r != nil {
+				// Cancel, then re-throw the panic so that Wait propagates it.
+				s.cancelUp()
+				panic(r)
+			}
+		}()
+		if err := f(s.ctx); err != nil {
+			s.fail(err)
+		}
+	})
+}
+
+// Context returns the Scope's context, which is canceled when the Scope
+// fails, when Cancel is called on it or one of its ancestors, or when Wait
+// returns.
+func (s *Scope) Context() context.Context {
+	return s.ctx
+}
+
+// Cancel cancels the Scope's context, and hence those of all its children.
+// It does not cancel the Scope's parent.
+func (s *Scope) Cancel() {
+	s.cancel()
+}
+
+// Child creates a new Scope nested in s. Its context is derived from the
+// context of s, and its errors and panics are propagated to s. Wait on s
+// also waits for the goroutines of its children.
+func (s *Scope) Child() *Scope {
+	child := newScope(s.ctx, s)
+	s.mu.Lock()
+	s.children = append(s.children, child)
+	s.mu.Unlock()
+	return child
+}
+
+// Wait blocks until all goroutines spawned in the Scope and in its children
+// exit, then cancels the Scope's context. It propagates the first panic
+// raised by any of these goroutines, and otherwise returns their errors
+// joined together, or nil if none of them failed.
+func (s *Scope) Wait() error {
+	recovered := s.wg.WaitAndRecover()
+
+	s.mu.Lock()
+	children := s.children
+	s.mu.Unlock()
+	for _, child := range children {
+		// A child's errors have already been reported to s, so only its
+		// panics are of interest.
+		if r := child.waitAndRecover(); recovered == nil {
+			recovered = r
+		}
+	}
+
+	s.cancel()
+	if recovered != nil {
+		panic(recovered)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if len(s.errs) == 0 {
+		return nil
+	}
+	s.waitErr = errors.Join(s.errs...)
+	return s.waitErr
+}
+
+// waitAndRecover is like Wait, but returns the first panic instead of
+// propagating it.
+func (s *Scope) waitAndRecover() (recovered *panics.Recovered) {
+	defer func() {
+		if r := recover(); r != nil {
+			recovered = r.(*panics.Recovered)
+		}
+	}()
+	_ = s.Wait()
+	return nil
+}
+
+// fail records err, cancels the Scope, and reports err to the parent.
+func (s *Scope) fail(err error) {
+	s.mu.Lock()
+	for _, child := range s.children {
+		if child.isWaitErr(err) {
+			// The error was returned by the child's Wait, possibly wrapped,
+			// and its parts have already been reported to s.
+			s.mu.Unlock()
+			return
+		}
+	}
+	s.errs = append(s.errs, err)
+	s.mu.Unlock()
+
+	s.cancel()
+	if s.parent != nil {
+		s.parent.fail(err)
+	}
+}
+
+// isWaitErr returns whether err is, or wraps, the error returned by Wait.
+func (s *Scope) isWaitErr(err error) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.waitErr != nil && errors.Is(err, s.waitErr)
+}
+
+// cancelUp cancels the Scope and all of its ancestors.
+func (s *Scope) cancelUp() {
+	for cur := s; cur != nil; cur = cur.parent {
+		cur.cancel()
+	}
+}
@@ -0,0 +1,183 @@
+package conc_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/sourcegraph/conc"
+
+	"github.com/stretchr/testify/require"
+)
+
+func ExampleScope() {
+	scope := conc.NewScope(context.Background())
+	scope.Go(func(ctx context.Context) error {
+		return errors.New("failed")
+	})
+	scope.Go(func(ctx context.Context) error {
+		// Canceled because the other goroutine failed.
+		<-ctx.Done()
+		return nil
+	})
+	fmt.Println(scope.Wait())
+	// Output:
+	// failed
+}
+
+func TestScope(t *testing.T) {
+	t.Parallel()
+
+	err1 := errors.New("err1")
+	err2 := errors.New("err2")
+
+	t.Run("no errors", func(t *testing.T) {
+		t.Parallel()
+		scope := conc.NewScope(context.Background())
+		var count atomic.Int64
+		for i := 0; i < 10; i++ {
+			scope.Go(func(context.Context) error {
+				count.Add(1)
+				return nil
+			})
+		}
+		require.NoError(t, scope.Wait())
+		require.Equal(t, int64(10), count.Load())
+		require.ErrorIs(t, scope.Context().Err(), context.Canceled)
+	})
+
+	t.Run("errors are collected", func(t *testing.T) {
+		t.Parallel()
+		scope := conc.NewScope(context.Background())
+		scope.Go(func(context.Context) error { return err1 })
+		scope.Go(func(context.Context) error { return err2 })
+		err := scope.Wait()
+		require.ErrorIs(t, err, err1)
+		require.ErrorIs(t, err, err2)
+	})
+
+	t.Run("first error cancels", func(t *testing.T) {
+		t.Parallel()
+		scope := conc.NewScope(context.Background())
+		scope.Go(func(ctx context.Context) error {
+			<-ctx.Done()
+			return nil
+		})
+		scope.Go(func(context.Context) error { return err1 })
+		require.ErrorIs(t, scope.Wait(), err1)
+	})
+
+	t.Run("panic cancels and is propagated", func(t *testing.T) {
+		t.Parallel()
+		scope := conc.NewScope(context.Background())
+		scope.Go(func(ctx context.Context) error {
+			<-ctx.Done()
+			return nil
+		})
+		scope.Go(func(context.Context) error { panic("super bad thing") })
+		require.Panics(t, func() { _ = scope.Wait() })
+	})
+
+	t.Run("cancel", func(t *testing.T) {
+		t.Parallel()
+		scope := conc.NewScope(context.Background())
+		child := scope.Child()
+		child.Go(func(ctx context.Context) error {
+			<-ctx.Done()
+			return ctx.Err()
+		})
+		scope.Cancel()
+		require.ErrorIs(t, scope.Wait(), context.Canceled)
+	})
+
+	t.Run("parent context", func(t *testing.T) {
+		t.Parallel()
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+		defer cancel()
+		scope := conc.NewScope(ctx)
+		scope.Go(func(ctx context.Context) error {
+			<-ctx.Done()
+			return ctx.Err()
+		})
+		require.ErrorIs(t, scope.Wait(), context.DeadlineExceeded)
+	})
+}
+
+func TestScopeChild(t *testing.T) {
+	t.Parallel()
+
+	err1 := errors.New("err1")
+
+	t.Run("child errors propagate upward", func(t *testing.T) {
+		t.Parallel()
+		scope := conc.NewScope(context.Background())
+		var siblingCanceled atomic.Bool
+		scope.Go(func(ctx context.Context) error {
+			<-ctx.Done()
+			siblingCanceled.Store(true)
+			return nil
+		})
+		scope.Go(func(context.Context) error {
+			child := scope.Child()
+			child.Go(func(context.Context) error { return err1 })
+			// Returning the child's error does not report it twice.
+			return child.Wait()
+		})
+		err := scope.Wait()
+		require.ErrorIs(t, err, err1)
+		require.Equal(t, "err1", err.Error())
+		require.True(t, siblingCanceled.Load())
+	})
+
+	t.Run("wrapped child errors are not reported twice", func(t *testing.T) {
+		t.Parallel()
+		scope := conc.NewScope(context.Background())
+		scope.Go(func(context.Context) error {
+			child := scope.Child()
+			child.Go(func(context.Context) error { return err1 })
+			return fmt.Errorf("child: %w", child.Wait())
+		})
+		err := scope.Wait()
+		require.ErrorIs(t, err, err1)
+		require.Equal(t, "err1", err.Error())
+	})
+
+	t.Run("parent waits for children", func(t *testing.T) {
+		t.Parallel()
+		scope := conc.NewScope(context.Background())
+		child := scope.Child().Child()
+		var done atomic.Bool
+		child.Go(func(context.Context) error {
+			time.Sleep(10 * time.Millisecond)
+			done.Store(true)
+			return nil
+		})
+		require.NoError(t, scope.Wait())
+		require.True(t, done.Load())
+	})
+
+	t.Run("child panics cancel and propagate to parent", func(t *testing.T) {
+		t.Parallel()
+		scope := conc.NewScope(context.Background())
+		scope.Go(func(ctx context.Context) error {
+			<-ctx.Done()
+			return nil
+		})
+		child := scope.Child()
+		child.Go(func(context.Context) error { panic("super bad thing") })
+		require.Panics(t, func() { _ = scope.Wait() })
+	})
+
+	t.Run("child cancellation does not affect parent", func(t *testing.T) {
+		t.Parallel()
+		scope := conc.NewScope(context.Background())
+		child := scope.Child()
+		child.Cancel()
+		require.ErrorIs(t, child.Context().Err(), context.Canceled)
+		require.NoError(t, scope.Context().Err())
+		require.NoError(t, scope.Wait())
+	})
+}
@@ -0,0 +1,205 @@
+package stream
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// NewPipeline creates a new Pipeline for items of type T. The context passed
+// to each stage is derived from ctx, and is canceled as soon as any stage
+// returns an error.
+func NewPipeline[T any](ctx context.Context) *Pipeline[T] {
+	ctx, cancel := context.WithCancel(ctx)
+	return &Pipeline[T]{
+		ctx:    ctx,
+		cancel: cancel,
+	}
+}
+
+// Pipeline chains several concurrent stages, each backed by its own Stream
+// with its own goroutine limit, while preserving the order of the items that
+// were submitted with Go().
+//
+// Each item passes through the stages in the order they were added. Once an
+// item has passed through every stage, the pipeline's callback is called
+// with the result. Like a Stream's callbacks, the pipeline's callback is
+// called sequentially, in the order the items were submitted.
+//
+// If any stage returns an error, the pipeline's context is canceled, the
+// failed item is dropped, and every item that has not yet started a stage is
+// dropped as well. Wait() returns the first error.
+//
+// The configuration methods (With*) will panic if they are used after calling
+// Go() for the first time. A Pipeline must not be reused after Wait().
+type Pipeline[T any] struct {
+	stages   []*stage[T]
+	callback func(T)
+
+	ctx    context.Context
+	cancel context.CancelFunc
+
+	started atomic.Bool
+
+	errMu sync.Mutex
+	err   error
+}
+
+// StageStats contains statistics about a pipeline stage. It can be used to
+// find the stages that are bottlenecks.
+type StageStats struct {
+	// Name is the name the stage was added with.
+	Name string
+	// MaxGoroutines is the stage's goroutine limit.
+	MaxGoroutines int
+	// Processed is the number of items the stage has completed, including the
+	// ones that errored.
+	Processed int64
+	// Errored is the number of items for which the stage returned an error.
+	Errored int64
+	// Busy is the total time spent in the stage, summed across goroutines.
+	Busy time.Duration
+}
+
+type stage[T any] struct {
+	name   string
+	f      func(context.Context, T) (T, error)
+	stream *Stream
+
+	processed atomic.Int64
+	errored   atomic.Int64
+	busy      atomic.Int64
+}
+
+// WithStage appends a stage to the pipeline that runs f on up to
+// maxGoroutines items concurrently. Panics if maxGoroutines < 1.
+func (p *Pipeline[T]) WithStage(name string, maxGoroutines int, f func(context.Context, T) (T, error)) *Pipeline[T] {
+	p.panicIfInitialized()
+	p.stages = append(p.stages, &stage[T]{
+		name:   name,
+		f:      f,
+		stream: New().WithMaxGoroutines(maxGoroutines),
+	})
+	return p
+}
+
+// WithCallback sets the function that is called with each item that passed
+// through every stage. Callbacks are called sequentially, in the order the
+// items were submitted.
+func (p *Pipeline[T]) WithCallback(f func(T)) *Pipeline[T] {
+	p.panicIfInitialized()
+	p.callback = f
+	return p
+}
+
+// Go submits an item to the first stage of the pipeline. If all goroutines
+// of the first stage are busy, a call to Go() will block until the item can
+// be started. Panics if the pipeline has no stages.
+func (p *Pipeline[T]) Go(item T) {
+	if len(p.stages) == 0 {
+		panic("pipeline must have at least one stage")
+	}
+	p.started.Store(true)
+	p.submit(0, item)
+}
+
+// Wait signals to the pipeline that all items have been submitted. Wait will
+// not return until all items have passed through the pipeline, and returns
+// the first error returned by a stage. If the pipeline's parent context is
+// canceled before all items were processed, the context's error is returned.
+//
+// Any panic from a stage or from the callback is propagated once all stages
+// have shut down.
+func (p *Pipeline[T]) Wait() error {
+	defer p.cancel()
+	p.wait(0)
+
+	p.errMu.Lock()
+	defer p.errMu.Unlock()
+	return p.err
+}
+
+// Stats returns statistics about each stage, in the order the stages were
+// added. It is safe to call while the pipeline is running.
+func (p *Pipeline[T]) Stats() []StageStats {
+	stats := make([]StageStats, 0, len(p.stages))
+	for _, st := range p.stages {
+		stats = append(stats, StageStats{
+			Name:          st.name,
+			MaxGoroutines: st.stream.pool.MaxGoroutines(),
+			Processed:     st.processed.Load(),
+			Errored:       st.errored.Load(),
+			Busy:          time.Duration(st.busy.Load()),
+		})
+	}
+	return stats
+}
+
+// wait waits for stage i and all stages after it. Stage i must be waited for
+// before stage i+1, since the callbacks of stage i submit to stage i+1. The
+// next stages are waited for in a defer so that they are cleaned up even if
+// stage i propagates a panic.
+func (p *Pipeline[T]) wait(i int) {
+	if i == len(p.stages) {
+		return
+	}
+	defer p.wait(i + 1)
+	p.stages[i].stream.Wait()
+}
+
+// submit runs item through stage i, then forwards the result to the next
+// stage from stage i's callback, which preserves submission order.
+func (p *Pipeline[T]) submit(i int, item T) {
+	st := p.stages[i]
+	st.stream.Go(func() Callback {
+		if err := p.ctx.Err(); err != nil {
+			p.setErr(err)
+			return nil
+		}
+
+		start := time.Now()
+		res, err := st.f(p.ctx, item)
+		st.busy.Add(int64(time.Since(start)))
+		st.processed.Add(1)
+		if err != nil {
+			st.errored.Add(1)
+			// Record the error before canceling so that items dropped
+			// because of the cancellation don't take precedence.
+			p.setErr(err)
+			p.cancel()
+			return nil
+		}
+
+		return func() {
+			if i+1 < len(p.stages) {
+				p.submit(i+1, res)
+				return
+			}
+			// The pipeline may have been canceled since the item passed the
+			// last stage, in which case its result is dropped like any
+			// other unfinished item.
+			if err := p.ctx.Err(); err != nil {
+				p.setErr(err)
+				return
+			}
+			if p.callback != nil {
+				p.callback(res)
+			}
+		}
+	})
+}
+
+func (p *Pipeline[T]) setErr(err error) {
+	p.errMu.Lock()
+	defer p.errMu.Unlock()
+	if p.err == nil {
+		p.err = err
+	}
+}
+
+func (p *Pipeline[T]) panicIfInitialized() {
+	if p.started.Load() {
+		panic("pipeline can not be reconfigured after calling Go() for the first time")
+	}
+}
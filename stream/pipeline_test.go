@@ -0,0 +1,222 @@
+package stream_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/sourcegraph/conc/stream"
+
+	"github.com/stretchr/testify/require"
+)
+
+func ExamplePipeline() {
+	p := stream.NewPipeline[int](context.Background()).
+		WithStage("double", 4, func(_ context.Context, i int) (int, error) {
+			time.Sleep(time.Duration(10-i) * time.Millisecond)
+			return i * 2, nil
+		}).
+		WithStage("increment", 2, func(_ context.Context, i int) (int, error) {
+			return i + 1, nil
+		}).
+		WithCallback(func(i int) { fmt.Println(i) })
+
+	for i := 0; i < 5; i++ {
+		p.Go(i)
+	}
+	_ = p.Wait()
+	// Output:
+	// 1
+	// 3
+	// 5
+	// 7
+	// 9
+}
+
+func TestPipeline(t *testing.T) {
+	t.Parallel()
+
+	bgctx := context.Background()
+
+	t.Run("order is preserved", func(t *testing.T) {
+		t.Parallel()
+		var res []string
+		p := stream.NewPipeline[string](bgctx).
+			WithStage("first", 8, func(_ context.Context, s string) (string, error) {
+				time.Sleep(time.Duration(len(s)%3) * time.Millisecond)
+				return s + "a", nil
+			}).
+			WithStage("second", 3, func(_ context.Context, s string) (string, error) {
+				return s + "b", nil
+			}).
+			WithCallback(func(s string) { res = append(res, s) })
+
+		var expected []string
+		for i := 0; i < 100; i++ {
+			p.Go(strconv.Itoa(i))
+			expected = append(expected, strconv.Itoa(i)+"ab")
+		}
+		require.NoError(t, p.Wait())
+		require.Equal(t, expected, res)
+	})
+
+	t.Run("stage limit", func(t *testing.T) {
+		t.Parallel()
+		var current atomic.Int64
+		var errCount atomic.Int64
+		p := stream.NewPipeline[int](bgctx).
+			WithStage("limited", 2, func(_ context.Context, i int) (int, error) {
+				if current.Add(1) > 2 {
+					errCount.Add(1)
+				}
+				time.Sleep(time.Millisecond)
+				current.Add(-1)
+				return i, nil
+			})
+		for i := 0; i < 20; i++ {
+			p.Go(i)
+		}
+		require.NoError(t, p.Wait())
+		require.Equal(t, int64(0), errCount.Load())
+	})
+
+	t.Run("error cancels the pipeline", func(t *testing.T) {
+		t.Parallel()
+		err1 := errors.New("err1")
+		var callbacks atomic.Int64
+		p := stream.NewPipeline[int](bgctx).
+			WithStage("fail", 1, func(_ context.Context, i int) (int, error) {
+				if i == 3 {
+					return 0, err1
+				}
+				return i, nil
+			}).
+			WithStage("wait", 1, func(ctx context.Context, i int) (int, error) {
+				return i, nil
+			}).
+			WithCallback(func(int) { callbacks.Add(1) })
+		for i := 0; i < 100; i++ {
+			p.Go(i)
+		}
+		require.ErrorIs(t, p.Wait(), err1)
+		require.Less(t, callbacks.Load(), int64(100))
+
+		stats := p.Stats()
+		require.Equal(t, int64(1), stats[0].Errored)
+		require.Equal(t, int64(0), stats[1].Errored)
+	})
+
+	t.Run("error drops results of the last stage", func(t *testing.T) {
+		t.Parallel()
+		err1 := errors.New("err1")
+		var callbacks atomic.Int64
+		p := stream.NewPipeline[int](bgctx).
+			WithStage("only", 2, func(ctx context.Context, i int) (int, error) {
+				if i == 1 {
+					return 0, err1
+				}
+				// Succeed only after the pipeline was canceled.
+				<-ctx.Done()
+				return i, nil
+			}).
+			WithCallback(func(int) { callbacks.Add(1) })
+		p.Go(0)
+		p.Go(1)
+		require.ErrorIs(t, p.Wait(), err1)
+		require.Equal(t, int64(0), callbacks.Load())
+	})
+
+	t.Run("concurrent go", func(t *testing.T) {
+		t.Parallel()
+		var callbacks atomic.Int64
+		p := stream.NewPipeline[int](bgctx).
+			WithStage("stage", 2, func(_ context.Context, i int) (int, error) {
+				return i, nil
+			}).
+			WithCallback(func(int) { callbacks.Add(1) })
+		var wg sync.WaitGroup
+		for i := 0; i < 10; i++ {
+			i := i
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				p.Go(i)
+			}()
+		}
+		wg.Wait()
+		require.NoError(t, p.Wait())
+		require.Equal(t, int64(10), callbacks.Load())
+	})
+
+	t.Run("parent cancellation is propagated", func(t *testing.T) {
+		t.Parallel()
+		ctx, cancel := context.WithCancel(bgctx)
+		p := stream.NewPipeline[int](ctx).
+			WithStage("stage", 1, func(ctx context.Context, i int) (int, error) {
+				return i, nil
+			})
+		cancel()
+		p.Go(1)
+		require.ErrorIs(t, p.Wait(), context.Canceled)
+	})
+
+	t.Run("panic is propagated", func(t *testing.T) {
+		t.Parallel()
+		p := stream.NewPipeline[int](bgctx).
+			WithStage("first", 2, func(_ context.Context, i int) (int, error) {
+				return i, nil
+			}).
+			WithStage("second", 2, func(_ context.Context, i int) (int, error) {
+				panic("oh no")
+			})
+		p.Go(1)
+		require.Panics(t, func() { _ = p.Wait() })
+	})
+
+	t.Run("stats", func(t *testing.T) {
+		t.Parallel()
+		p := stream.NewPipeline[int](bgctx).
+			WithStage("first", 4, func(_ context.Context, i int) (int, error) {
+				time.Sleep(time.Millisecond)
+				return i, nil
+			}).
+			WithStage("second", 1, func(_ context.Context, i int) (int, error) {
+				return i, nil
+			})
+		for i := 0; i < 10; i++ {
+			p.Go(i)
+		}
+		require.NoError(t, p.Wait())
+
+		stats := p.Stats()
+		require.Len(t, stats, 2)
+		require.Equal(t, "first", stats[0].Name)
+		require.Equal(t, 4, stats[0].MaxGoroutines)
+		require.Equal(t, int64(10), stats[0].Processed)
+		require.GreaterOrEqual(t, stats[0].Busy, 10*time.Millisecond)
+		require.Equal(t, "second", stats[1].Name)
+		require.Equal(t, int64(10), stats[1].Processed)
+	})
+
+	t.Run("panics without stages", func(t *testing.T) {
+		t.Parallel()
+		p := stream.NewPipeline[int](bgctx)
+		require.Panics(t, func() { p.Go(1) })
+	})
+
+	t.Run("panics on configuration after go", func(t *testing.T) {
+		t.Parallel()
+		p := stream.NewPipeline[int](bgctx).
+			WithStage("stage", 1, func(_ context.Context, i int) (int, error) {
+				return i, nil
+			})
+		p.Go(1)
+		require.Panics(t, func() { p.WithCallback(func(int) {}) })
+		require.NoError(t, p.Wait())
+	})
+}
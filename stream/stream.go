@@ -2,7 +2,10 @@
 package stream
 
 import (
+	"errors"
+	"fmt"
 	"sync"
+	"sync/atomic"
 
 	"github.com/sourcegraph/conc"
 	"github.com/sourcegraph/conc/panics"
@@ -29,7 +32,8 @@ func New() *Stream {
 //
 // In the case of panic during execution of a task or a callback, all other
 // tasks and callbacks will still execute. The panic will be propagated to the
-// caller when Wait() is called.
+// caller when Wait() is called. This behavior can be changed with
+// WithPanicPolicy().
 //
 // A Stream is efficient, but not zero cost. It should not be used for very
 // short tasks. Startup and teardown adds an overhead of a couple of
@@ -38,11 +42,67 @@ func New() *Stream {
 type Stream struct {
 	pool             pool.Pool
 	callbackerHandle conc.WaitGroup
-	queue            chan callbackCh
+	queue            chan queuedTask
+
+	panicPolicy PanicPolicy
+	// submitMu makes taking the next index and queueing the task atomic,
+	// so that the queue is in index order even when Go is called
+	// concurrently.
+	submitMu sync.Mutex
+	// submitted is the number of tasks submitted with Go.
+	submitted int
+	// stopAt is one more than the lowest index of a task that panicked, or
+	// whose callback panicked, under PanicStop. Zero means nothing panicked.
+	stopAt atomic.Int64
+	// panics is only written by the callbacker, and is only read after the
+	// callbacker has exited.
+	panics []Panic
 
 	initOnce sync.Once
 }
 
+// PanicPolicy controls how a Stream behaves when a task or a callback panics.
+type PanicPolicy int
+
+const (
+	// PanicContinue runs all remaining tasks and callbacks after a panic, then
+	// propagates the first panic to the caller of Wait(). This is the default.
+	PanicContinue PanicPolicy = iota
+
+	// PanicStop skips the tasks that have not yet started and the callbacks
+	// that have not yet run once a panic is caught, if they were submitted
+	// after the task that panicked, then propagates the panic to the caller
+	// of Wait(). Tasks and callbacks submitted before it still run, so the
+	// callbacks that run are always those of a prefix of the stream.
+	PanicStop
+
+	// PanicAsError runs all remaining tasks and callbacks after a panic, and
+	// does not propagate panics from Wait(). Recovered panics are instead
+	// available with Err() and Panics().
+	PanicAsError
+)
+
+// Panic is a panic that was recovered from a task or a callback.
+type Panic struct {
+	// Index is the submission index of the task, starting at 0 for the first
+	// task passed to Go().
+	Index int
+	// InCallback is true if the panic was raised by the task's callback rather
+	// than by the task itself.
+	InCallback bool
+	// Recovered is the recovered panic.
+	Recovered *panics.Recovered
+}
+
+// AsError converts the panic into an error that records the task's index.
+// The returned error unwraps to a *panics.ErrRecovered.
+func (p Panic) AsError() error {
+	if p.InCallback {
+		return fmt.Errorf("callback of task %d panicked: %w", p.Index, p.Recovered.AsError())
+	}
+	return fmt.Errorf("task %d panicked: %w", p.Index, p.Recovered.AsError())
+}
+
 // Task is a task that is submitted to the stream. Submitted tasks will
 // be executed concurrently. It returns a callback that will be called after
 // the task has completed.
@@ -59,6 +119,9 @@ type Callback func()
 // synchronization is necessary between callbacks. If all goroutines in the
 // stream's pool are busy, a call to Go() will block until the task can be
 // started.
+//
+// Go can be called concurrently. Tasks are then indexed, and their callbacks
+// run, in the order the calls to Go queued them.
 func (s *Stream) Go(f Task) {
 	s.init()
 
@@ -66,50 +129,95 @@ func (s *Stream) Go(f Task) {
 	ch := getCh()
 
 	// Queue the channel for the callbacker.
-	s.queue <- ch
+	s.submitMu.Lock()
+	idx := s.submitted
+	s.submitted++
+	s.queue <- queuedTask{ch: ch, idx: idx}
+	s.submitMu.Unlock()
 
 	// Submit the task for execution.
 	s.pool.Go(func() {
-		defer func() {
-			// In the case of a panic from f, we don't want the callbacker to
-			// starve waiting for a callback from this channel, so give it an
-			// empty callback.
-			if r := recover(); r != nil {
-				ch <- func() {}
-				panic(r)
-			}
-		}()
+		if s.isStopped(idx) {
+			// An earlier task or callback panicked with PanicStop, so don't
+			// bother running this one.
+			ch <- taskResult{}
+			return
+		}
 
-		// Run the task, sending its callback down this task's channel.
-		callback := f()
-		ch <- callback
+		// Run the task, sending its callback down this task's channel. In the
+		// case of a panic from f, we don't want the callbacker to starve
+		// waiting on this channel, so send it the recovered panic instead.
+		var callback Callback
+		if recovered := panics.Try(func() { callback = f() }); recovered != nil {
+			if s.panicPolicy == PanicStop {
+				s.stop(idx)
+			}
+			ch <- taskResult{recovered: recovered}
+			return
+		}
+		ch <- taskResult{callback: callback}
 	})
 }
 
 // Wait signals to the stream that all tasks have been submitted. Wait will
 // not return until all tasks and callbacks have been run.
+//
+// Unless the stream is configured with PanicAsError, Wait propagates the
+// panic of the task with the lowest index that panicked, if any.
 func (s *Stream) Wait() {
 	s.init()
 
 	// Defer the callbacker cleanup so that it occurs even in the case
-	// that one of the tasks panics and is propagated up by s.pool.Wait().
+	// that s.pool.Wait() panics.
 	defer func() {
 		close(s.queue)
 		s.callbackerHandle.Wait()
+
+		if s.panicPolicy != PanicAsError && len(s.panics) > 0 {
+			panic(s.panics[0].Recovered)
+		}
 	}()
 
 	// Wait for all the workers to exit.
 	s.pool.Wait()
 }
 
+// Panics returns every panic recovered from the stream's tasks and callbacks,
+// ordered by task index. It must only be called after Wait() has returned
+// (or panicked).
+func (s *Stream) Panics() []Panic {
+	return append([]Panic(nil), s.panics...)
+}
+
+// Err returns the panics recovered from the stream's tasks and callbacks as a
+// combined error, or nil if nothing panicked. It must only be called after
+// Wait() has returned (or panicked).
+func (s *Stream) Err() error {
+	errs := make([]error, 0, len(s.panics))
+	for _, p := range s.panics {
+		errs = append(errs, p.AsError())
+	}
+	return errors.Join(errs...)
+}
+
 func (s *Stream) WithMaxGoroutines(n int) *Stream {
 	s.pool.WithMaxGoroutines(n)
 	return s
 }
 
+// WithPanicPolicy configures how the stream behaves when a task or a callback
+// panics. Defaults to PanicContinue. Panics if called after Go().
+func (s *Stream) WithPanicPolicy(policy PanicPolicy) *Stream {
+	if s.queue != nil {
+		panic("stream can not be reconfigured after calling Go() for the first time")
+	}
+	s.panicPolicy = policy
+	return s
+}
+
 func (s *Stream) init() {
 	s.initOnce.Do(func() {
-		s.queue = make(chan callbackCh, s.pool.MaxGoroutines()+1)
+		s.queue = make(chan queuedTask, s.pool.MaxGoroutines()+1)
 
 		// Start the callbacker.
 		s.callbackerHandle.Go(s.callbacker)
@@ -119,25 +227,78 @@ func (s *Stream) init() {
 // callbacker is responsible for calling the returned callbacks in the order
 // they were submitted. There is only a single instance of callbacker running.
 func (s *Stream) callbacker() {
-	var panicCatcher panics.Catcher
-	defer panicCatcher.Repanic()
-
 	// For every scheduled task, read that tasks channel from the queue.
-	for callbackCh := range s.queue {
-		// Wait for the task to complete and get its callback from the channel.
-		callback := <-callbackCh
+	for {
+		queued, ok := <-s.queue
+		if !ok {
+			return
+		}
+		idx := queued.idx
+
+		// Wait for the task to complete and get its result from the channel.
+		res := <-queued.ch
+
+		// Return the channel to the pool of unused channels.
+		putCh(queued.ch)
+
+		if res.recovered != nil {
+			s.addPanic(Panic{Index: idx, Recovered: res.recovered})
+			continue
+		}
 
 		// Execute the callback (with panic protection).
-		if callback != nil {
-			panicCatcher.Try(callback)
+		if res.callback != nil && !s.isStopped(idx) {
+			if recovered := panics.Try(res.callback); recovered != nil {
+				s.addPanic(Panic{Index: idx, InCallback: true, Recovered: recovered})
+			}
 		}
+	}
+}
 
-		// Return the channel to the pool of unused channels.
-		putCh(callbackCh)
+// addPanic records a recovered panic. It must only be called by the
+// callbacker.
+func (s *Stream) addPanic(p Panic) {
+	if s.panicPolicy == PanicStop {
+		s.stop(p.Index)
+	}
+	s.panics = append(s.panics, p)
+}
+
+// stop records that the task with the given index, or its callback,
+// panicked under PanicStop, so that later tasks and callbacks are skipped.
+func (s *Stream) stop(idx int) {
+	for {
+		cur := s.stopAt.Load()
+		if cur != 0 && cur <= int64(idx)+1 {
+			return
+		}
+		if s.stopAt.CompareAndSwap(cur, int64(idx)+1) {
+			return
+		}
 	}
 }
 
-type callbackCh chan func()
+// isStopped returns whether the task with the given index, and its callback,
+// should be skipped because an earlier task or callback panicked under
+// PanicStop.
+func (s *Stream) isStopped(idx int) bool {
+	stopAt := s.stopAt.Load()
+	return stopAt != 0 && int64(idx) >= stopAt
+}
+
+// queuedTask is queued for the callbacker by Go.
+type queuedTask struct {
+	ch  callbackCh
+	idx int
+}
+
+// taskResult is sent by a task to the callbacker once the task completes.
+type taskResult struct {
+	callback  Callback
+	recovered *panics.Recovered
+}
+
+type callbackCh chan taskResult
 
 var callbackChPool = sync.Pool{
 	New: func() any {
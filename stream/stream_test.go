@@ -1,7 +1,9 @@
 package stream_test
 
 import (
+	"errors"
 	"fmt"
+	"sync"
 	"sync/atomic"
 	"testing"
 	"time"
@@ -133,6 +135,138 @@ func TestStream(t *testing.T) {
 		}
 		require.Panics(t, s.Wait)
 	})
+
+	t.Run("all panics are recorded with their index", func(t *testing.T) {
+		t.Parallel()
+		s := stream.New().WithMaxGoroutines(5)
+		for i := 0; i < 10; i++ {
+			i := i
+			s.Go(func() stream.Callback {
+				if i == 3 {
+					panic("task")
+				}
+				return func() {
+					if i == 7 {
+						panic("callback")
+					}
+				}
+			})
+		}
+		require.Panics(t, s.Wait)
+
+		p := s.Panics()
+		require.Len(t, p, 2)
+		require.Equal(t, 3, p[0].Index)
+		require.False(t, p[0].InCallback)
+		require.Equal(t, "task", p[0].Recovered.Value)
+		require.Equal(t, 7, p[1].Index)
+		require.True(t, p[1].InCallback)
+		require.Equal(t, "callback", p[1].Recovered.Value)
+	})
+
+	t.Run("panics are ordered with concurrent go", func(t *testing.T) {
+		t.Parallel()
+		s := stream.New().WithMaxGoroutines(4).WithPanicPolicy(stream.PanicAsError)
+		var wg sync.WaitGroup
+		for i := 0; i < 8; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				for j := 0; j < 100; j++ {
+					s.Go(func() stream.Callback {
+						panic("task")
+					})
+				}
+			}()
+		}
+		wg.Wait()
+		s.Wait()
+
+		p := s.Panics()
+		require.Len(t, p, 800)
+		for i := range p {
+			require.Equal(t, i, p[i].Index)
+		}
+	})
+
+	t.Run("panic as error", func(t *testing.T) {
+		t.Parallel()
+		err1 := errors.New("err1")
+		s := stream.New().WithMaxGoroutines(5).WithPanicPolicy(stream.PanicAsError)
+		var callbacks int
+		for i := 0; i < 10; i++ {
+			i := i
+			s.Go(func() stream.Callback {
+				if i == 5 {
+					panic(err1)
+				}
+				return func() { callbacks++ }
+			})
+		}
+		require.NotPanics(t, s.Wait)
+		require.Equal(t, 9, callbacks)
+		require.ErrorIs(t, s.Err(), err1)
+		require.Len(t, s.Panics(), 1)
+	})
+
+	t.Run("panic stop skips remaining callbacks", func(t *testing.T) {
+		t.Parallel()
+		s := stream.New().WithMaxGoroutines(1).WithPanicPolicy(stream.PanicStop)
+		var callbacks int
+		for i := 0; i < 10; i++ {
+			i := i
+			s.Go(func() stream.Callback {
+				return func() {
+					callbacks++
+					if i == 2 {
+						panic("stop")
+					}
+				}
+			})
+		}
+		require.Panics(t, s.Wait)
+		require.Equal(t, 3, callbacks)
+		require.Len(t, s.Panics(), 1)
+	})
+
+	t.Run("stop keeps callbacks of earlier tasks", func(t *testing.T) {
+		t.Parallel()
+		s := stream.New().WithMaxGoroutines(4).WithPanicPolicy(stream.PanicStop)
+		var callbacks []int
+		for i := 0; i < 10; i++ {
+			i := i
+			s.Go(func() stream.Callback {
+				switch i {
+				case 0:
+					time.Sleep(50 * time.Millisecond)
+				case 1:
+					panic("stop")
+				}
+				return func() { callbacks = append(callbacks, i) }
+			})
+		}
+		require.Panics(t, s.Wait)
+		require.Equal(t, []int{0}, callbacks)
+		require.Len(t, s.Panics(), 1)
+		require.Equal(t, 1, s.Panics()[0].Index)
+	})
+
+	t.Run("no panics", func(t *testing.T) {
+		t.Parallel()
+		s := stream.New()
+		s.Go(func() stream.Callback { return nil })
+		s.Wait()
+		require.Empty(t, s.Panics())
+		require.NoError(t, s.Err())
+	})
+
+	t.Run("panics on configuration after go", func(t *testing.T) {
+		t.Parallel()
+		s := stream.New()
+		s.Go(func() stream.Callback { return nil })
+		require.Panics(t, func() { s.WithPanicPolicy(stream.PanicStop) })
+		s.Wait()
+	})
 }
 
 func BenchmarkStream(b *testing.B) {
@@ -0,0 +1,339 @@
+// Package supervisor runs long-lived goroutines and restarts them when they
+// fail, in the style of Erlang/OTP supervisors.
+package supervisor
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync/atomic"
+	"time"
+
+	"github.com/sourcegraph/conc"
+	"github.com/sourcegraph/conc/panics"
+)
+
+// Strategy determines which children a Supervisor restarts when one of them
+// fails.
+type Strategy int
+
+const (
+	// OneForOne restarts only the child that failed. This is the default.
+	OneForOne Strategy = iota
+	// OneForAll stops all the other running children when a child fails,
+	// and restarts all of them. It is suitable for children that depend on
+	// each other.
+	OneForAll
+	// RestForOne stops and restarts the child that failed, and the running
+	// children that were added after it. It is suitable for children that
+	// depend on the children added before them.
+	RestForOne
+)
+
+// ErrTooManyRestarts is returned by Run when children failed more often than
+// allowed by the Supervisor's restart intensity.
+var ErrTooManyRestarts = errors.New("too many restarts")
+
+const (
+	defaultMaxRestarts = 1
+	defaultPeriod      = 5 * time.Second
+)
+
+// New creates a new Supervisor.
+func New() *Supervisor {
+	return &Supervisor{
+		maxRestarts: defaultMaxRestarts,
+		period:      defaultPeriod,
+	}
+}
+
+// Supervisor runs a set of long-lived children, each in its own goroutine,
+// and restarts them when they fail, according to its Strategy.
+//
+// A child fails when it returns an error or panics. A child that returns nil
+// is done, and is not restarted. When children fail more often than the
+// restart intensity allows, the Supervisor gives up: it stops all of its
+// children and Run returns an error that wraps ErrTooManyRestarts and the
+// last failure.
+//
+// Since Run has the signature of a child, supervisors can be nested by
+// adding the Run method of one Supervisor as a child of another.
+//
+// The configuration methods (With*) and Add will panic if they are used
+// after calling Run() for the first time.
+type Supervisor struct {
+	strategy    Strategy
+	maxRestarts int
+	period      time.Duration
+	minBackoff  time.Duration
+	maxBackoff  time.Duration
+	children    []childSpec
+
+	started atomic.Bool
+}
+
+type childSpec struct {
+	name string
+	f    func(ctx context.Context) error
+}
+
+// WithStrategy sets the restart strategy of the Supervisor. Defaults to
+// OneForOne.
+func (s *Supervisor) WithStrategy(strategy Strategy) *Supervisor {
+	s.panicIfStarted()
+	s.strategy = strategy
+	return s
+}
+
+// WithIntensity sets the restart intensity of the Supervisor: if more than
+// maxRestarts restarts happen within period, the Supervisor gives up.
+// Defaults to 1 restart in 5 seconds, like Erlang/OTP. Panics if maxRestarts
+// is negative or period is not positive.
+func (s *Supervisor) WithIntensity(maxRestarts int, period time.Duration) *Supervisor {
+	s.panicIfStarted()
+	if maxRestarts < 0 {
+		panic("max restarts of a supervisor must not be negative")
+	}
+	if period <= 0 {
+		panic("restart period of a supervisor must be greater than zero")
+	}
+	s.maxRestarts = maxRestarts
+	s.period = period
+	return s
+}
+
+// WithBackoff delays restarts of a child that keeps failing. The first
+// restart is delayed by min, and the delay doubles with each consecutive
+// failure of the same child, up to max. A child's failures are no longer
+// consecutive once it has run for longer than the restart period. Defaults
+// to restarting immediately. Panics if min is not positive or max < min.
+func (s *Supervisor) WithBackoff(min, max time.Duration) *Supervisor {
+	s.panicIfStarted()
+	if min <= 0 {
+		panic("min backoff of a supervisor must be greater than zero")
+	}
+	if max < min {
+		panic("max backoff of a supervisor must not be less than min backoff")
+	}
+	s.minBackoff = min
+	s.maxBackoff = max
+	return s
+}
+
+// Add adds a child to the Supervisor. The name identifies the child in
+// errors. f should run until ctx is canceled, and return nil once it is.
+func (s *Supervisor) Add(name string, f func(ctx context.Context) error) *Supervisor {
+	s.panicIfStarted()
+	s.children = append(s.children, childSpec{name: name, f: f})
+	return s
+}
+
+// Run starts all the children in the order they were added, and supervises
+// them until all of them are done, until ctx is canceled, or until the
+// Supervisor gives up. In the latter two cases, the remaining children are
+// canceled, and Run waits for them to exit before returning.
+//
+// Run returns nil if all the children are done or ctx is canceled, and an
+// error wrapping ErrTooManyRestarts if the Supervisor gave up.
+func (s *Supervisor) Run(ctx context.Context) error {
+	s.started.Store(true)
+	r := &run{
+		Supervisor: s,
+		states:     make([]childState, len(s.children)),
+		exits:      make(chan exit),
+	}
+	return r.run(ctx)
+}
+
+func (s *Supervisor) panicIfStarted() {
+	if s.started.Load() {
+		panic("supervisor can not be reconfigured after calling Run() for the first time")
+	}
+}
+
+// run holds the state of a single call to Run.
+type run struct {
+	*Supervisor
+
+	wg     conc.WaitGroup
+	ctx    context.Context
+	states []childState
+	exits  chan exit
+
+	// live is the number of child goroutines that have not reported their
+	// exit yet.
+	live int
+	// restarts holds the times of the restarts within the last period.
+	restarts []time.Time
+}
+
+type childState struct {
+	cancel  context.CancelFunc
+	running bool
+	done    bool
+	// failures is the number of consecutive failures, used for backoff.
+	failures int
+	// restart is set when the child was stopped to be restarted after
+	// delay, as part of the failure of another child.
+	restart bool
+	delay   time.Duration
+}
+
+type exit struct {
+	child int
+	err   error
+	ran   time.Duration
+}
+
+func (r *run) run(ctx context.Context) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	r.ctx = ctx
+
+	for i := range r.states {
+		r.start(i, 0)
+	}
+
+	var (
+		stopping bool
+		err      error
+	)
+	stop := func() {
+		stopping = true
+		cancel()
+	}
+	done := ctx.Done()
+	for r.live > 0 {
+		select {
+		case <-done:
+			done = nil
+			stop()
+		case e := <-r.exits:
+			r.live--
+			if stopping {
+				continue
+			}
+			if failErr := r.exited(e); failErr != nil {
+				err = failErr
+				stop()
+			}
+		}
+	}
+	r.wg.Wait()
+	return err
+}
+
+// start runs child i in a new goroutine, after delay.
+func (r *run) start(i int, delay time.Duration) {
+	ctx, cancel := context.WithCancel(r.ctx)
+	st := &r.states[i]
+	st.cancel = cancel
+	st.running = true
+	r.live++
+
+	f := r.children[i].f
+	r.wg.Go(func() {
+		defer cancel()
+		if delay > 0 {
+			timer := time.NewTimer(delay)
+			select {
+			case <-timer.C:
+			case <-ctx.Done():
+				timer.Stop()
+				r.exits <- exit{child: i, err: ctx.Err()}
+				return
+			}
+		}
+
+		started := time.Now()
+		var err error
+		if recovered := panics.Try(func() { err = f(ctx) }); recovered != nil {
+			err = recovered.AsError()
+		}
+		r.exits <- exit{child: i, err: err, ran: time.Since(started)}
+	})
+}
+
+// exited handles the exit of a child, and returns a non-nil error if the
+// Supervisor should give up.
+func (r *run) exited(e exit) error {
+	st := &r.states[e.child]
+	st.running = false
+
+	if st.restart {
+		// The child was stopped because another child failed.
+		st.restart = false
+		r.start(e.child, st.delay)
+		return nil
+	}
+	if e.err == nil {
+		st.done = true
+		return nil
+	}
+
+	now := time.Now()
+	r.restarts = append(r.restarts, now)
+	for len(r.restarts) > 0 && now.Sub(r.restarts[0]) > r.period {
+		r.restarts = r.restarts[1:]
+	}
+	if len(r.restarts) > r.maxRestarts {
+		return fmt.Errorf("supervisor: child %q: %w: %w", r.children[e.child].name, ErrTooManyRestarts, e.err)
+	}
+
+	if e.ran > r.period {
+		st.failures = 0
+	}
+	st.failures++
+	delay := r.backoff(st.failures)
+
+	for i := range r.states {
+		if !r.affected(e.child, i) {
+			continue
+		}
+		other := &r.states[i]
+		switch {
+		case i == e.child:
+			r.start(i, delay)
+		case other.running && !other.restart:
+			other.restart = true
+			other.delay = delay
+			other.cancel()
+		}
+	}
+	return nil
+}
+
+// affected returns whether child i should be restarted when child failed
+// fails.
+func (r *run) affected(failed, i int) bool {
+	if i == failed {
+		return true
+	}
+	if r.states[i].done {
+		return false
+	}
+	switch r.strategy {
+	case OneForAll:
+		return true
+	case RestForOne:
+		return i > failed
+	default:
+		return false
+	}
+}
+
+// backoff returns the delay before restarting a child that failed failures
+// times in a row.
+func (r *run) backoff(failures int) time.Duration {
+	if r.minBackoff == 0 {
+		return 0
+	}
+	delay := r.minBackoff
+	for i := 1; i < failures && delay < r.maxBackoff; i++ {
+		delay *= 2
+	}
+	if delay > r.maxBackoff {
+		delay = r.maxBackoff
+	}
+	return delay
+}
@@ -0,0 +1,227 @@
+package supervisor_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/sourcegraph/conc/supervisor"
+
+	"github.com/stretchr/testify/require"
+)
+
+func ExampleSupervisor() {
+	ctx, cancel := context.WithCancel(context.Background())
+	var attempts atomic.Int64
+
+	sup := supervisor.New().
+		Add("worker", func(ctx context.Context) error {
+			if attempts.Add(1) == 1 {
+				return errors.New("flaky")
+			}
+			fmt.Println("worker is running")
+			cancel()
+			<-ctx.Done()
+			return nil
+		})
+	fmt.Println(sup.Run(ctx))
+	// Output:
+	// worker is running
+	// <nil>
+}
+
+// waitUntilCanceled is a child that runs until its context is canceled.
+func waitUntilCanceled(starts *atomic.Int64) func(context.Context) error {
+	return func(ctx context.Context) error {
+		starts.Add(1)
+		<-ctx.Done()
+		return nil
+	}
+}
+
+// failOnce is a child that fails the first time it runs, and then runs until
+// its context is canceled.
+func failOnce(starts *atomic.Int64) func(context.Context) error {
+	return func(ctx context.Context) error {
+		if starts.Add(1) == 1 {
+			return errors.New("failed")
+		}
+		<-ctx.Done()
+		return nil
+	}
+}
+
+// runUntil runs sup until cond is true, then cancels it and returns the
+// result of Run.
+func runUntil(t *testing.T, sup *supervisor.Supervisor, cond func() bool) error {
+	ctx, cancel := context.WithCancel(context.Background())
+	errc := make(chan error, 1)
+	go func() { errc <- sup.Run(ctx) }()
+	require.Eventually(t, cond, 5*time.Second, time.Millisecond)
+	cancel()
+	return <-errc
+}
+
+func TestSupervisor(t *testing.T) {
+	t.Parallel()
+
+	t.Run("no children", func(t *testing.T) {
+		t.Parallel()
+		require.NoError(t, supervisor.New().Run(context.Background()))
+	})
+
+	t.Run("done children are not restarted", func(t *testing.T) {
+		t.Parallel()
+		var starts atomic.Int64
+		sup := supervisor.New()
+		for i := 0; i < 3; i++ {
+			sup.Add(fmt.Sprint(i), func(context.Context) error {
+				starts.Add(1)
+				return nil
+			})
+		}
+		require.NoError(t, sup.Run(context.Background()))
+		require.Equal(t, int64(3), starts.Load())
+	})
+
+	t.Run("stops cleanly", func(t *testing.T) {
+		t.Parallel()
+		var starts atomic.Int64
+		sup := supervisor.New().
+			Add("a", waitUntilCanceled(&starts)).
+			Add("b", waitUntilCanceled(&starts))
+		err := runUntil(t, sup, func() bool { return starts.Load() == 2 })
+		require.NoError(t, err)
+	})
+
+	t.Run("one for one", func(t *testing.T) {
+		t.Parallel()
+		var a, b atomic.Int64
+		sup := supervisor.New().
+			Add("a", failOnce(&a)).
+			Add("b", waitUntilCanceled(&b))
+		err := runUntil(t, sup, func() bool { return a.Load() == 2 && b.Load() == 1 })
+		require.NoError(t, err)
+		require.Equal(t, int64(1), b.Load())
+	})
+
+	t.Run("one for all", func(t *testing.T) {
+		t.Parallel()
+		var a, b atomic.Int64
+		sup := supervisor.New().
+			WithStrategy(supervisor.OneForAll).
+			Add("a", waitUntilCanceled(&a))
+		// Make sure b only fails once a is running.
+		sup.Add("b", func(ctx context.Context) error {
+			for a.Load() == 0 {
+				time.Sleep(time.Millisecond)
+			}
+			return failOnce(&b)(ctx)
+		})
+		err := runUntil(t, sup, func() bool { return a.Load() == 2 && b.Load() == 2 })
+		require.NoError(t, err)
+	})
+
+	t.Run("rest for one", func(t *testing.T) {
+		t.Parallel()
+		var a, b, c atomic.Int64
+		sup := supervisor.New().
+			WithStrategy(supervisor.RestForOne).
+			Add("a", waitUntilCanceled(&a))
+		// Make sure b only fails once c is running.
+		sup.Add("b", func(ctx context.Context) error {
+			for c.Load() == 0 {
+				time.Sleep(time.Millisecond)
+			}
+			return failOnce(&b)(ctx)
+		})
+		sup.Add("c", waitUntilCanceled(&c))
+		err := runUntil(t, sup, func() bool { return b.Load() == 2 && c.Load() == 2 })
+		require.NoError(t, err)
+		require.Equal(t, int64(1), a.Load())
+	})
+
+	t.Run("panics are restarted", func(t *testing.T) {
+		t.Parallel()
+		var starts atomic.Int64
+		sup := supervisor.New().Add("a", func(ctx context.Context) error {
+			if starts.Add(1) == 1 {
+				panic("super bad thing")
+			}
+			<-ctx.Done()
+			return nil
+		})
+		err := runUntil(t, sup, func() bool { return starts.Load() == 2 })
+		require.NoError(t, err)
+	})
+
+	t.Run("too many restarts", func(t *testing.T) {
+		t.Parallel()
+		err1 := errors.New("always failing")
+		var starts, other atomic.Int64
+		sup := supervisor.New().
+			WithIntensity(3, time.Minute).
+			Add("failing", func(context.Context) error {
+				starts.Add(1)
+				return err1
+			}).
+			Add("other", waitUntilCanceled(&other))
+		err := sup.Run(context.Background())
+		require.ErrorIs(t, err, supervisor.ErrTooManyRestarts)
+		require.ErrorIs(t, err, err1)
+		require.Contains(t, err.Error(), `"failing"`)
+		require.Equal(t, int64(4), starts.Load())
+	})
+
+	t.Run("backoff", func(t *testing.T) {
+		t.Parallel()
+		var mu sync.Mutex
+		var starts []time.Time
+		sup := supervisor.New().
+			WithIntensity(2, time.Minute).
+			WithBackoff(20*time.Millisecond, 30*time.Millisecond).
+			Add("failing", func(context.Context) error {
+				mu.Lock()
+				starts = append(starts, time.Now())
+				mu.Unlock()
+				return errors.New("failed")
+			})
+		require.ErrorIs(t, sup.Run(context.Background()), supervisor.ErrTooManyRestarts)
+		require.Len(t, starts, 3)
+		require.GreaterOrEqual(t, starts[1].Sub(starts[0]), 20*time.Millisecond)
+		require.GreaterOrEqual(t, starts[2].Sub(starts[1]), 30*time.Millisecond)
+	})
+
+	t.Run("nested", func(t *testing.T) {
+		t.Parallel()
+		var starts atomic.Int64
+		inner := supervisor.New().
+			WithIntensity(0, time.Minute).
+			Add("inner", failOnce(&starts))
+		outer := supervisor.New().Add("supervisor", inner.Run)
+		// The inner supervisor gives up on the first failure, and the outer
+		// supervisor restarts it.
+		err := runUntil(t, outer, func() bool { return starts.Load() == 2 })
+		require.NoError(t, err)
+	})
+
+	t.Run("panics on configuration after run", func(t *testing.T) {
+		t.Parallel()
+		sup := supervisor.New()
+		require.NoError(t, sup.Run(context.Background()))
+		require.Panics(t, func() { sup.Add("a", func(context.Context) error { return nil }) })
+		require.Panics(t, func() { sup.WithStrategy(supervisor.OneForAll) })
+	})
+
+	t.Run("invalid configuration", func(t *testing.T) {
+		t.Parallel()
+		require.Panics(t, func() { supervisor.New().WithIntensity(-1, time.Second) })
+		require.Panics(t, func() { supervisor.New().WithIntensity(1, 0) })
+		require.Panics(t, func() { supervisor.New().WithBackoff(0, time.Second) })
+		require.Panics(t, func() { supervisor.New().WithBackoff(time.Second, time.Millisecond) })
+	})
+}
@@ -1,8 +1,14 @@
 package conc
 
 import (
+	"context"
+	"runtime"
+	"runtime/pprof"
 	"sync"
+	"sync/atomic"
+	"time"
 
+	"github.com/sourcegraph/conc/internal/registry"
 	"github.com/sourcegraph/conc/panics"
 )
 
@@ -22,17 +28,158 @@ func NewWaitGroup() *WaitGroup {
 type WaitGroup struct {
 	wg sync.WaitGroup
 	pc panics.Catcher
+
+	captureSpawn bool
+	track        bool
+	running      registry.Registry
+
+	// live counts the goroutines that have not exited yet, so that
+	// WaitContext can tell whether there is anything to wait for.
+	live atomic.Int64
+
+	// waiter is closed once all goroutines have exited. It is shared by the
+	// calls to WaitContext that wait at the same time.
+	waiterMu sync.Mutex
+	waiter   chan struct{}
+}
+
+// WithPanicObserver configures an observer that is notified synchronously
+// whenever a goroutine spawned in the WaitGroup panics, rather than only when
+// Wait propagates the panic. It must be called before the first call to Go.
+func (h *WaitGroup) WithPanicObserver(f panics.Observer) *WaitGroup {
+	h.pc.Observer = f
+	return h
+}
+
+// WithPanicFilter configures which panics are caught by the WaitGroup.
+// Panics for which filter returns false are not propagated to Wait, but
+// re-raised immediately in the goroutine that panicked, which crashes the
+// program unless the goroutine's function recovers them itself. It must be
+// called before the first call to Go.
+func (h *WaitGroup) WithPanicFilter(filter func(value any) bool) *WaitGroup {
+	h.pc.Filter = filter
+	return h
+}
+
+// WithSpawnStack configures the WaitGroup to capture the stack of the caller
+// of Go, so that a recovered panic also shows where the panicking goroutine
+// was launched from, in its SpawnCallers. Capturing the stack has a cost on
+// every call to Go, so it is disabled by default. It must be called before
+// the first call to Go.
+func (h *WaitGroup) WithSpawnStack() *WaitGroup {
+	h.captureSpawn = true
+	return h
+}
+
+// WithTracking configures the WaitGroup to keep track of every goroutine
+// spawned with Go while it runs, so that Running and the *WaitError of
+// WaitContext list them along with their spawn location. Goroutines spawned
+// with GoNamed are always tracked. Tracking has a cost on every call to Go,
+// so it is disabled by default. It must be called before the first call to
+// Go.
+func (h *WaitGroup) WithTracking() *WaitGroup {
+	h.track = true
+	return h
+}
+
+// WithRecoverAll configures the WaitGroup to retain the panics of all its
+// goroutines, up to panics.DefaultMaxRecovered of them, rather than only the
+// first one, so that WaitAndRecoverAll returns them. It must be called before
+// the first call to Go.
+func (h *WaitGroup) WithRecoverAll() *WaitGroup {
+	h.pc.MaxRecovered = panics.DefaultMaxRecovered
+	return h
 }
 
+// PprofLabel is the runtime/pprof label that holds the name of goroutines
+// spawned with GoNamed, and of tasks submitted with GoNamed to a pool.
+const PprofLabel = "goroutine"
+
 // Go spawns a new goroutine in the WaitGroup.
 func (h *WaitGroup) Go(f func()) {
-	h.wg.Add(1)
+	if h.track || h.captureSpawn {
+		h.spawn("", f)
+		return
+	}
+
+	h.start()
 	go func() {
-		defer h.wg.Done()
+		defer h.exit()
 		h.pc.Try(f)
 	}()
 }
 
+// GoNamed spawns a new goroutine in the WaitGroup, like Go. The goroutine is
+// tracked, even if WithTracking was not used, and the name identifies it in
+// Running and in a *WaitError. The name is also set as the PprofLabel label
+// of the goroutine, so that CPU and goroutine profiles can be attributed to
+// it.
+func (h *WaitGroup) GoNamed(name string, f func()) {
+	h.spawn(name, func() {
+		pprof.Do(context.Background(), pprof.Labels(PprofLabel, name), func(context.Context) {
+			f()
+		})
+	})
+}
+
+// spawn is the slow path of Go, for tracked goroutines or when the spawn
+// stack is captured. It must be called directly by the method that was
+// called by the user, so that the spawn location is the user's code.
+func (h *WaitGroup) spawn(name string, f func()) {
+	var (
+		spawn []uintptr
+		pc    uintptr
+	)
+	if h.captureSpawn {
+		// 64 frames should be plenty
+		var callers [64]uintptr
+		n := runtime.Callers(3, callers[:])
+		spawn = callers[:n]
+		pc = spawn[0]
+	} else {
+		var callers [1]uintptr
+		runtime.Callers(3, callers[:])
+		pc = callers[0]
+	}
+
+	if !h.track && name == "" {
+		h.start()
+		go func() {
+			defer h.exit()
+			h.pc.TryFrom(spawn, f)
+		}()
+		return
+	}
+
+	id := h.running.Add(name, pc)
+	h.start()
+	go func() {
+		defer h.exit()
+		defer h.running.Remove(id)
+		h.pc.TryFrom(spawn, f)
+	}()
+}
+
+// start records that a goroutine is about to be spawned.
+func (h *WaitGroup) start() {
+	h.live.Add(1)
+	h.wg.Add(1)
+}
+
+// exit records that a spawned goroutine exited.
+func (h *WaitGroup) exit() {
+	h.live.Add(-1)
+	h.wg.Done()
+}
+
+// Running returns the tracked goroutines of the WaitGroup that have not
+// exited yet, in the order they were spawned. Goroutines spawned with Go are
+// only tracked if WithTracking was used. It is safe to call concurrently
+// with Go and Wait, for example from a debug endpoint or a shutdown timeout.
+func (h *WaitGroup) Running() []RunningGoroutine {
+	return runningGoroutines(h.running.Snapshot())
+}
+
 // Wait will block until all goroutines spawned with Go exit and will
 // propagate any panics spawned in a child goroutine.
 func (h *WaitGroup) Wait() {
@@ -42,6 +189,69 @@ func (h *WaitGroup) Wait() {
 	h.pc.Repanic()
 }
 
+// WaitContext is like Wait, but gives up waiting once ctx is done. In that
+// case, it returns a *WaitError that wraps the context's error and lists the
+// tracked goroutines that are still running (see WithTracking). The
+// goroutines are not stopped, so Wait should still be called eventually.
+//
+// Waiting is done by a helper goroutine, which keeps running after an
+// abandoned wait until the WaitGroup's goroutines exit. It is shared by all
+// concurrent and abandoned calls to WaitContext, so repeated calls on a hung
+// WaitGroup don't pile up goroutines.
+func (h *WaitGroup) WaitContext(ctx context.Context) error {
+	if h.live.Load() != 0 {
+		select {
+		case <-h.waitDone():
+		case <-ctx.Done():
+			// The goroutines may have finished at the same time, so check
+			// again rather than report a spurious error.
+			if h.live.Load() != 0 {
+				return &WaitError{Err: ctx.Err(), Running: runningGoroutines(h.running.Snapshot())}
+			}
+		}
+	}
+
+	// All goroutines are exiting, if they haven't already, so this won't
+	// block for long.
+	h.wg.Wait()
+
+	// Propagate a panic if we caught one from a child goroutine.
+	h.pc.Repanic()
+	return nil
+}
+
+// waitDone returns a channel that is closed once all goroutines have
+// exited, starting the helper goroutine that waits for them if no call is
+// waiting already.
+func (h *WaitGroup) waitDone() <-chan struct{} {
+	h.waiterMu.Lock()
+	defer h.waiterMu.Unlock()
+
+	if h.waiter == nil {
+		waiter := make(chan struct{})
+		h.waiter = waiter
+		go func() {
+			h.wg.Wait()
+
+			// Let the next wait start a new helper, since more goroutines
+			// may be spawned once these are done.
+			h.waiterMu.Lock()
+			h.waiter = nil
+			h.waiterMu.Unlock()
+			close(waiter)
+		}()
+	}
+	return h.waiter
+}
+
+// WaitTimeout is like WaitContext, but gives up waiting after d, in which
+// case the *WaitError wraps context.DeadlineExceeded.
+func (h *WaitGroup) WaitTimeout(d time.Duration) error {
+	ctx, cancel := context.WithTimeout(context.Background(), d)
+	defer cancel()
+	return h.WaitContext(ctx)
+}
+
 // WaitAndRecover will block until all goroutines spawned with Go exit and
 // will return a *panics.Recovered if one of the child goroutines panics.
 func (h *WaitGroup) WaitAndRecover() *panics.Recovered {
@@ -50,3 +260,14 @@ func (h *WaitGroup) WaitAndRecover() *panics.Recovered {
 	// Return a recovered panic if we caught one from a child goroutine.
 	return h.pc.Recovered()
 }
+
+// WaitAndRecoverAll will block until all goroutines spawned with Go exit and
+// will return every panic raised by the child goroutines, in the order they
+// were caught, or nil if none of them panicked. Unless the WaitGroup was
+// configured with WithRecoverAll, only the first panic is retained, and
+// hence returned.
+func (h *WaitGroup) WaitAndRecoverAll() []*panics.Recovered {
+	h.wg.Wait()
+
+	return h.pc.RecoveredAll()
+}
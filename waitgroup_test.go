@@ -1,11 +1,19 @@
 package conc_test
 
 import (
+	"bytes"
+	"context"
+	"errors"
 	"fmt"
+	"runtime"
+	"runtime/pprof"
+	"strings"
 	"sync/atomic"
 	"testing"
+	"time"
 
 	"github.com/sourcegraph/conc"
+	"github.com/sourcegraph/conc/panics"
 
 	"github.com/stretchr/testify/require"
 )
@@ -154,5 +162,294 @@ func TestWaitGroup(t *testing.T) {
 			require.Equal(t, p.Value, "super bad thing")
 			require.Equal(t, int64(2), i.Load())
 		})
+
+		t.Run("all are caught by waitandrecoverall", func(t *testing.T) {
+			t.Parallel()
+			var wg conc.WaitGroup
+			wg.WithRecoverAll()
+			for i := 0; i < 10; i++ {
+				i := i
+				wg.Go(func() {
+					panic(i)
+				})
+			}
+			wg.Go(func() {})
+			ps := wg.WaitAndRecoverAll()
+			require.Len(t, ps, 10)
+			var values []int
+			for _, p := range ps {
+				values = append(values, p.Value.(int))
+			}
+			require.ElementsMatch(t, []int{0, 1, 2, 3, 4, 5, 6, 7, 8, 9}, values)
+		})
+
+		t.Run("is observed before wait", func(t *testing.T) {
+			t.Parallel()
+			observed := make(chan any, 1)
+			var wg conc.WaitGroup
+			wg.WithPanicObserver(func(r *panics.Recovered, _ panics.Goroutine) {
+				observed <- r.Value
+			})
+			wg.Go(func() {
+				panic("super bad thing")
+			})
+			require.Equal(t, "super bad thing", <-observed)
+			require.Panics(t, wg.Wait)
+		})
+
+		t.Run("filter sees panic values", func(t *testing.T) {
+			t.Parallel()
+			var seen atomic.Value
+			var wg conc.WaitGroup
+			wg.WithPanicFilter(func(value any) bool {
+				seen.Store(value)
+				return true
+			})
+			wg.Go(func() {
+				panic("super bad thing")
+			})
+			p := wg.WaitAndRecover()
+			require.Equal(t, "super bad thing", p.Value)
+			require.Equal(t, "super bad thing", seen.Load())
+		})
+
+		t.Run("spawn stack is captured", func(t *testing.T) {
+			t.Parallel()
+			var wg conc.WaitGroup
+			wg.WithSpawnStack()
+			spawner := func() {
+				wg.Go(func() {
+					panic("super bad thing")
+				})
+			}
+			spawner()
+			p := wg.WaitAndRecover()
+			require.NotNil(t, p)
+			frames := p.SpawnFrames()
+			require.NotEmpty(t, frames)
+			require.Contains(t, frames[0].Function, "TestWaitGroup")
+			require.Contains(t, p.String(), "spawned by:")
+		})
+
+		t.Run("spawn stack is not captured by default", func(t *testing.T) {
+			t.Parallel()
+			var wg conc.WaitGroup
+			wg.Go(func() {
+				panic("super bad thing")
+			})
+			p := wg.WaitAndRecover()
+			require.Nil(t, p.SpawnCallers)
+		})
+
+		t.Run("only the first is retained by default", func(t *testing.T) {
+			t.Parallel()
+			var wg conc.WaitGroup
+			for i := 0; i < 10; i++ {
+				wg.Go(func() {
+					panic("super bad thing")
+				})
+			}
+			ps := wg.WaitAndRecoverAll()
+			require.Len(t, ps, 1)
+			require.Equal(t, wg.WaitAndRecover(), ps[0])
+		})
+
+		t.Run("none are caught by waitandrecoverall", func(t *testing.T) {
+			t.Parallel()
+			var wg conc.WaitGroup
+			wg.Go(func() {})
+			require.Nil(t, wg.WaitAndRecoverAll())
+		})
+	})
+}
+
+func TestWaitGroupWaitContext(t *testing.T) {
+	t.Parallel()
+
+	t.Run("all goroutines exit", func(t *testing.T) {
+		t.Parallel()
+		var wg conc.WaitGroup
+		var count atomic.Int64
+		for i := 0; i < 10; i++ {
+			wg.Go(func() { count.Add(1) })
+		}
+		require.NoError(t, wg.WaitContext(context.Background()))
+		require.Equal(t, int64(10), count.Load())
+	})
+
+	t.Run("panic is propagated", func(t *testing.T) {
+		t.Parallel()
+		var wg conc.WaitGroup
+		wg.Go(func() { panic("super bad thing") })
+		require.Panics(t, func() { _ = wg.WaitContext(context.Background()) })
+	})
+
+	t.Run("running goroutines are reported", func(t *testing.T) {
+		t.Parallel()
+		var wg conc.WaitGroup
+		wg.WithTracking()
+		release := make(chan struct{})
+		wg.Go(func() {})
+		wg.Go(func() { <-release })
+
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		// Make sure the first goroutine has exited.
+		require.Eventually(t, func() bool {
+			var waitErr *conc.WaitError
+			err := wg.WaitContext(ctx)
+			return errors.As(err, &waitErr) && len(waitErr.Running) == 1
+		}, time.Second, time.Millisecond)
+
+		err := wg.WaitContext(ctx)
+		require.ErrorIs(t, err, context.Canceled)
+		var waitErr *conc.WaitError
+		require.ErrorAs(t, err, &waitErr)
+		require.Contains(t, waitErr.Running[0].Location.Function, "TestWaitGroupWaitContext")
+		require.True(t, strings.HasSuffix(waitErr.Running[0].Location.File, "waitgroup_test.go"))
+		require.Contains(t, err.Error(), "1 tracked goroutines still running")
+
+		close(release)
+		wg.Wait()
+	})
+
+	t.Run("no goroutines with canceled context", func(t *testing.T) {
+		t.Parallel()
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		for i := 0; i < 1000; i++ {
+			var wg conc.WaitGroup
+			require.NoError(t, wg.WaitContext(ctx))
+		}
+
+		var wg conc.WaitGroup
+		wg.Go(func() {})
+		wg.Wait()
+		require.NoError(t, wg.WaitContext(ctx))
+	})
+
+	t.Run("timeout", func(t *testing.T) {
+		t.Parallel()
+		var wg conc.WaitGroup
+		release := make(chan struct{})
+		wg.Go(func() { <-release })
+		err := wg.WaitTimeout(10 * time.Millisecond)
+		require.ErrorIs(t, err, context.DeadlineExceeded)
+
+		var waitErr *conc.WaitError
+		require.ErrorAs(t, err, &waitErr)
+		require.Empty(t, waitErr.Running, "goroutines are not tracked by default")
+
+		close(release)
+		require.NoError(t, wg.WaitTimeout(time.Minute))
+	})
+}
+
+// TestWaitGroupWaitContextHelper is not parallel so that other tests don't
+// change the number of goroutines while it runs.
+func TestWaitGroupWaitContextHelper(t *testing.T) {
+	var wg conc.WaitGroup
+	release := make(chan struct{})
+	wg.Go(func() { <-release })
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	require.Error(t, wg.WaitContext(ctx))
+	before := runtime.NumGoroutine()
+	for i := 0; i < 100; i++ {
+		require.Error(t, wg.WaitContext(ctx))
+	}
+	require.Equal(t, before, runtime.NumGoroutine())
+
+	close(release)
+	require.NoError(t, wg.WaitTimeout(time.Minute))
+}
+
+func TestWaitGroupGoNamed(t *testing.T) {
+	t.Parallel()
+
+	t.Run("running", func(t *testing.T) {
+		t.Parallel()
+		var wg conc.WaitGroup
+		release := make(chan struct{})
+		wg.GoNamed("stuck", func() { <-release })
+		wg.Go(func() { <-release })
+
+		// Only named goroutines are tracked by default.
+		running := wg.Running()
+		require.Len(t, running, 1)
+		require.Equal(t, "stuck", running[0].Name)
+
+		close(release)
+		wg.Wait()
+		require.Empty(t, wg.Running())
+	})
+
+	t.Run("running with tracking", func(t *testing.T) {
+		t.Parallel()
+		var wg conc.WaitGroup
+		wg.WithTracking()
+		release := make(chan struct{})
+		before := time.Now()
+		wg.GoNamed("stuck", func() { <-release })
+		wg.Go(func() { <-release })
+
+		running := wg.Running()
+		require.Len(t, running, 2)
+		require.Equal(t, "stuck", running[0].Name)
+		require.Equal(t, "", running[1].Name)
+		for _, g := range running {
+			require.False(t, g.Started.Before(before))
+			require.Contains(t, g.Location.Function, "TestWaitGroupGoNamed")
+		}
+		require.Contains(t, running[0].String(), `"stuck" spawned at`)
+
+		close(release)
+		wg.Wait()
+		require.Empty(t, wg.Running())
+	})
+
+	t.Run("pprof label", func(t *testing.T) {
+		t.Parallel()
+		var wg conc.WaitGroup
+		started, release := make(chan struct{}), make(chan struct{})
+		wg.GoNamed("labeled-goroutine", func() {
+			close(started)
+			<-release
+		})
+		<-started
+
+		var buf bytes.Buffer
+		require.NoError(t, pprof.Lookup("goroutine").WriteTo(&buf, 1))
+		require.Contains(t, buf.String(), `"goroutine":"labeled-goroutine"`)
+
+		close(release)
+		wg.Wait()
+	})
+
+	t.Run("panic is propagated", func(t *testing.T) {
+		t.Parallel()
+		var wg conc.WaitGroup
+		wg.GoNamed("panicky", func() { panic("super bad thing") })
+		require.Equal(t, "super bad thing", wg.WaitAndRecover().Value)
+	})
+}
+
+func BenchmarkWaitGroup(b *testing.B) {
+	b.Run("go", func(b *testing.B) {
+		var wg conc.WaitGroup
+		for i := 0; i < b.N; i++ {
+			wg.Go(func() {})
+		}
+		wg.Wait()
+	})
+
+	b.Run("go with tracking", func(b *testing.B) {
+		var wg conc.WaitGroup
+		wg.WithTracking()
+		for i := 0; i < b.N; i++ {
+			wg.Go(func() {})
+		}
+		wg.Wait()
 	})
 }